@@ -0,0 +1,484 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertState 告警状态
+type AlertState int
+
+const (
+	// AlertInactive 条件不满足
+	AlertInactive AlertState = iota
+	// AlertPending 条件满足但持续时间未达到For
+	AlertPending
+	// AlertFiring 条件持续满足，已触发告警
+	AlertFiring
+	// AlertResolved 告警触发后条件不再满足
+	AlertResolved
+)
+
+func (s AlertState) String() string {
+	switch s {
+	case AlertPending:
+		return "pending"
+	case AlertFiring:
+		return "firing"
+	case AlertResolved:
+		return "resolved"
+	default:
+		return "inactive"
+	}
+}
+
+func (s AlertState) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *AlertState) UnmarshalText(text []byte) error {
+	for state := AlertInactive; state <= AlertResolved; state++ {
+		if state.String() == string(text) {
+			*s = state
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown alert state %q", text)
+}
+
+// AlertSelector 选择规则统计的错误，按Code、Type、Severity的优先级使用第一个非零字段，
+// 都为零值时统计全部错误
+type AlertSelector struct {
+	// 错误码
+	Code string `json:"code,omitempty"`
+	// 错误类型
+	Type ErrType `json:"type,omitempty"`
+	// 最低严重程度，统计不低于该严重程度的错误
+	Severity Severity `json:"severity,omitempty"`
+}
+
+// count 返回快照中选择的累计错误数
+func (s AlertSelector) count(snapshot MonitorSnapshot) int64 {
+	switch {
+	case s.Code != "":
+		return snapshot.ByCode[s.Code]
+	case s.Type != "":
+		return snapshot.ByType[s.Type]
+	case s.Severity != SeverityByType:
+		var total int64
+		for severity, n := range snapshot.BySeverity {
+			if severity >= s.Severity {
+				total += n
+			}
+		}
+		return total
+	default:
+		return snapshot.Total
+	}
+}
+
+func (s AlertSelector) String() string {
+	switch {
+	case s.Code != "":
+		return "code " + s.Code
+	case s.Type != "":
+		return "type " + s.Type.String()
+	case s.Severity != SeverityByType:
+		return "severity>=" + s.Severity.String()
+	default:
+		return "all errors"
+	}
+}
+
+// AlertSeries 规则选择的累计错误数时间序列
+type AlertSeries interface {
+	// Now 返回本次评估的时间
+	Now() time.Time
+	// CountAt 返回不晚于t的最近一次采样的累计错误数和采样时间，没有足够早的采样时ok为false
+	CountAt(t time.Time) (count int64, at time.Time, ok bool)
+	// Earliest 返回最早一次采样的累计错误数和采样时间
+	Earliest() (count int64, at time.Time)
+}
+
+// AlertCondition 告警条件
+type AlertCondition interface {
+	// Check 返回条件的当前值以及条件是否满足
+	Check(series AlertSeries) (value float64, ok bool)
+	// Lookback 返回条件需要的历史长度
+	Lookback() time.Duration
+	// String 返回条件的描述
+	String() string
+}
+
+// rateAbove 窗口内的平均速率超过阈值
+type rateAbove struct {
+	perSecond float64
+	window    time.Duration
+}
+
+// RateAbove 窗口内每秒的平均错误数超过perSecond时满足条件，
+// 历史不足一个窗口时使用已有的历史计算
+func RateAbove(perSecond float64, window time.Duration) AlertCondition {
+	return rateAbove{perSecond: perSecond, window: window}
+}
+
+func (c rateAbove) Check(series AlertSeries) (float64, bool) {
+	now := series.Now()
+	current, _, _ := series.CountAt(now)
+	past, at, ok := series.CountAt(now.Add(-c.window))
+	if !ok {
+		past, at = series.Earliest()
+	}
+
+	elapsed := now.Sub(at)
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	rate := float64(current-past) / elapsed.Seconds()
+	return rate, rate > c.perSecond
+}
+
+func (c rateAbove) Lookback() time.Duration {
+	return c.window
+}
+
+func (c rateAbove) String() string {
+	return fmt.Sprintf("rate > %g/s over %s", c.perSecond, c.window)
+}
+
+// increaseAbove 当前窗口的错误数相比上一个窗口的增幅超过阈值
+type increaseAbove struct {
+	percent float64
+	window  time.Duration
+}
+
+// IncreaseAbove 当前窗口的错误数相比上一个窗口增加超过percent（300表示增加300%，即变为4倍）时满足条件，
+// 历史不足两个窗口或上一个窗口没有错误时不满足
+func IncreaseAbove(percent float64, window time.Duration) AlertCondition {
+	return increaseAbove{percent: percent, window: window}
+}
+
+func (c increaseAbove) Check(series AlertSeries) (float64, bool) {
+	now := series.Now()
+	current, _, _ := series.CountAt(now)
+	middle, _, _ := series.CountAt(now.Add(-c.window))
+	past, _, ok := series.CountAt(now.Add(-2 * c.window))
+	if !ok || middle == past {
+		return 0, false
+	}
+
+	increase := float64((current-middle)-(middle-past)) / float64(middle-past) * 100
+	return increase, increase > c.percent
+}
+
+func (c increaseAbove) Lookback() time.Duration {
+	return 2 * c.window
+}
+
+func (c increaseAbove) String() string {
+	return fmt.Sprintf("increase > %g%% vs previous %s", c.percent, c.window)
+}
+
+// AlertRule 告警规则
+type AlertRule struct {
+	// 规则名称，同时作为告警去重的键
+	Name string
+	// 统计的错误
+	Selector AlertSelector
+	// 告警条件
+	Condition AlertCondition
+	// 条件需要持续满足的时间，0表示满足后立即触发
+	For time.Duration
+	// 告警的严重程度，未指定时为warning
+	Severity Severity
+	// 附加的标签，原样带到告警中
+	Labels map[string]string
+}
+
+// Alert 规则的告警状态
+type Alert struct {
+	// 规则名称
+	Rule string `json:"rule"`
+	// 告警状态
+	State AlertState `json:"state"`
+	// 告警的严重程度
+	Severity Severity `json:"severity"`
+	// 告警描述
+	Description string `json:"description"`
+	// 条件的当前值
+	Value float64 `json:"value"`
+	// 附加的标签
+	Labels map[string]string `json:"labels,omitempty"`
+	// 条件开始满足的时间
+	ActiveAt time.Time `json:"activeAt,omitzero"`
+	// 告警触发的时间
+	FiredAt time.Time `json:"firedAt,omitzero"`
+	// 告警恢复的时间
+	ResolvedAt time.Time `json:"resolvedAt,omitzero"`
+}
+
+// alertRuleState 规则以及当前的告警状态
+type alertRuleState struct {
+	rule  AlertRule
+	alert Alert
+	// 最近一次发送通知的时间，用于重复通知的去重
+	notifiedAt time.Time
+}
+
+// alertSample 一次评估时的监控快照
+type alertSample struct {
+	at       time.Time
+	snapshot MonitorSnapshot
+}
+
+// AlertEngine 基于错误监控的告警引擎，每次评估时读取监控快照，按规则计算条件，
+// 维护pending、firing、resolved状态并在状态变化时通知，并发安全
+type AlertEngine struct {
+	mu sync.Mutex
+	// 错误监控
+	monitor *Monitor
+	// 时钟
+	clock Clock
+	// 规则，按添加顺序排列
+	rules []*alertRuleState
+	// 通知器
+	notifiers []AlertNotifier
+	// 告警持续触发时重复通知的间隔，0表示不重复通知
+	repeatInterval time.Duration
+	// 历史快照，按时间从旧到新排列
+	history []alertSample
+}
+
+type AlertOption func(e *AlertEngine)
+
+// WithAlertClock 设置时钟，默认为系统时钟
+func WithAlertClock(clock Clock) AlertOption {
+	return func(e *AlertEngine) {
+		e.clock = clock
+	}
+}
+
+// WithAlertNotifiers 添加通知器
+func WithAlertNotifiers(notifiers ...AlertNotifier) AlertOption {
+	return func(e *AlertEngine) {
+		e.notifiers = append(e.notifiers, notifiers...)
+	}
+}
+
+// WithAlertRepeatInterval 设置告警持续触发时重复通知的间隔，默认不重复通知
+func WithAlertRepeatInterval(d time.Duration) AlertOption {
+	return func(e *AlertEngine) {
+		e.repeatInterval = d
+	}
+}
+
+// NewAlertEngine 创建告警引擎，监控为nil时每次评估都读取到空快照
+func NewAlertEngine(m *Monitor, opts ...AlertOption) *AlertEngine {
+	e := &AlertEngine{
+		monitor: m,
+		clock:   SystemClock{},
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	return e
+}
+
+// AddRule 添加规则，名称为空、重复或没有条件时返回错误
+func (e *AlertEngine) AddRule(rule AlertRule) error {
+	if rule.Name == "" {
+		return errors.New("alert rule: missing name")
+	}
+	if rule.Condition == nil {
+		return fmt.Errorf("alert rule %q: missing condition", rule.Name)
+	}
+	if rule.Severity == SeverityByType {
+		rule.Severity = SeverityWarning
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, state := range e.rules {
+		if state.rule.Name == rule.Name {
+			return fmt.Errorf("alert rule %q: duplicate name", rule.Name)
+		}
+	}
+
+	e.rules = append(e.rules, &alertRuleState{
+		rule:  rule,
+		alert: Alert{Rule: rule.Name, Severity: rule.Severity, Labels: rule.Labels},
+	})
+	return nil
+}
+
+// Alerts 返回所有非inactive的告警，按规则添加顺序排列
+func (e *AlertEngine) Alerts() []Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var alerts []Alert
+	for _, state := range e.rules {
+		if state.alert.State != AlertInactive {
+			alerts = append(alerts, state.alert)
+		}
+	}
+
+	return alerts
+}
+
+// Evaluate 读取一次监控快照并评估所有规则，状态变化的告警会发送给所有通知器，
+// 返回通知失败的错误
+func (e *AlertEngine) Evaluate(ctx context.Context) error {
+	now := e.clock.Now()
+	snapshot := e.monitor.Snapshot()
+
+	e.mu.Lock()
+	e.record(now, snapshot)
+
+	var notifications []Alert
+	for _, state := range e.rules {
+		series := alertSeries{now: now, history: e.history, selector: state.rule.Selector}
+		value, ok := state.rule.Condition.Check(series)
+		if e.transition(state, now, value, ok) {
+			state.notifiedAt = now
+			notifications = append(notifications, state.alert)
+		}
+	}
+	e.mu.Unlock()
+
+	// 通知在锁外发送，避免慢速的通知器阻塞评估
+	var errs []error
+	for _, alert := range notifications {
+		for _, notifier := range e.notifiers {
+			if err := notifier.Notify(ctx, alert); err != nil {
+				errs = append(errs, fmt.Errorf("notify %s alert %q: %w", alert.State, alert.Rule, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run 每隔interval评估一次规则，直到ctx结束，通知失败的错误交给onError处理，onError可以为nil
+func (e *AlertEngine) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	for {
+		if err := e.Evaluate(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.clock.After(interval):
+		}
+	}
+}
+
+// record 保存快照并清理超出最长回溯时间的历史，保留一个不晚于回溯起点的采样，需要持有锁
+func (e *AlertEngine) record(now time.Time, snapshot MonitorSnapshot) {
+	e.history = append(e.history, alertSample{at: now, snapshot: snapshot})
+
+	var lookback time.Duration
+	for _, state := range e.rules {
+		lookback = max(lookback, state.rule.Condition.Lookback())
+	}
+
+	cutoff := now.Add(-lookback)
+	keep := sort.Search(len(e.history), func(i int) bool {
+		return e.history[i].at.After(cutoff)
+	})
+	if keep > 1 {
+		e.history = append(e.history[:0], e.history[keep-1:]...)
+	}
+}
+
+// transition 根据条件更新告警状态，返回是否需要通知，需要持有锁
+func (e *AlertEngine) transition(state *alertRuleState, now time.Time, value float64, ok bool) bool {
+	alert := &state.alert
+	alert.Value = value
+
+	if !ok {
+		switch alert.State {
+		case AlertFiring:
+			alert.State = AlertResolved
+			alert.ResolvedAt = now
+			return true
+		case AlertPending:
+			alert.State = AlertInactive
+			alert.ActiveAt = time.Time{}
+		}
+		return false
+	}
+
+	switch alert.State {
+	case AlertInactive, AlertResolved:
+		alert.State = AlertPending
+		alert.ActiveAt = now
+		alert.FiredAt, alert.ResolvedAt = time.Time{}, time.Time{}
+		alert.Description = fmt.Sprintf("%s: %s", state.rule.Selector, state.rule.Condition)
+	case AlertFiring:
+		// 持续触发时按重复间隔去重
+		return e.repeatInterval > 0 && now.Sub(state.notifiedAt) >= e.repeatInterval
+	}
+
+	if now.Sub(alert.ActiveAt) >= state.rule.For {
+		alert.State = AlertFiring
+		alert.FiredAt = now
+		return true
+	}
+
+	return false
+}
+
+// alertSeries 从历史快照中读取选择的累计错误数
+type alertSeries struct {
+	now      time.Time
+	history  []alertSample
+	selector AlertSelector
+}
+
+func (s alertSeries) Now() time.Time {
+	return s.now
+}
+
+func (s alertSeries) CountAt(t time.Time) (int64, time.Time, bool) {
+	i := sort.Search(len(s.history), func(i int) bool {
+		return s.history[i].at.After(t)
+	})
+	if i == 0 {
+		return 0, time.Time{}, false
+	}
+
+	sample := s.history[i-1]
+	return s.selector.count(sample.snapshot), sample.at, true
+}
+
+func (s alertSeries) Earliest() (int64, time.Time) {
+	if len(s.history) == 0 {
+		return 0, s.now
+	}
+
+	return s.selector.count(s.history[0].snapshot), s.history[0].at
+}
@@ -0,0 +1,221 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// recordN 向监控记录n次错误
+func recordN(m *Monitor, code *ErrCode, n int) {
+	for range n {
+		m.Record(FastNew(code))
+	}
+}
+
+// TestAlertEngine_Rate 测试速率规则的pending、firing、resolved状态和通知去重
+func TestAlertEngine_Rate(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMonitor()
+	ch := make(chan Alert, 10)
+	e := NewAlertEngine(m, WithAlertClock(clock), WithAlertNotifiers(ChannelNotifier(ch)))
+	err := e.AddRule(AlertRule{
+		Name:      "internal-rate",
+		Selector:  AlertSelector{Type: ErrTypeInternal},
+		Condition: RateAbove(5, time.Minute),
+		For:       2 * time.Minute,
+		Severity:  SeverityCritical,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	evaluate := func(internal int) {
+		t.Helper()
+		recordN(m, ErrInternal, internal)
+		recordN(m, ErrNotFound, 1000)
+		clock.Advance(time.Minute)
+		if err := e.Evaluate(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	states := []AlertState{}
+	for _, n := range []int{0, 600, 600, 600, 600, 0, 0} {
+		evaluate(n)
+		alerts := e.Alerts()
+		if len(alerts) == 0 {
+			states = append(states, AlertInactive)
+			continue
+		}
+		states = append(states, alerts[0].State)
+	}
+
+	want := []AlertState{AlertInactive, AlertPending, AlertPending, AlertFiring, AlertFiring, AlertResolved, AlertResolved}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Fatalf("states = %v, want %v", states, want)
+		}
+	}
+
+	close(ch)
+	var notified []Alert
+	for alert := range ch {
+		notified = append(notified, alert)
+	}
+	if len(notified) != 2 || notified[0].State != AlertFiring || notified[1].State != AlertResolved {
+		t.Fatalf("notified = %+v", notified)
+	}
+	if notified[0].Value != 10 || notified[0].Severity != SeverityCritical || notified[0].Description == "" {
+		t.Errorf("firing = %+v", notified[0])
+	}
+}
+
+// TestAlertEngine_Increase 测试相比上一个窗口的增幅规则和重复通知
+func TestAlertEngine_Increase(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMonitor()
+	var notified []Alert
+	notifier := AlertNotifierFunc(func(_ context.Context, alert Alert) error {
+		notified = append(notified, alert)
+		return nil
+	})
+	e := NewAlertEngine(m, WithAlertClock(clock), WithAlertNotifiers(notifier), WithAlertRepeatInterval(2*time.Hour))
+	_ = e.AddRule(AlertRule{
+		Name:      "payment-spike",
+		Selector:  AlertSelector{Code: ErrTimeout.Code},
+		Condition: IncreaseAbove(300, time.Hour),
+	})
+
+	_ = e.Evaluate(context.Background())
+	recordN(m, ErrTimeout, 10)
+	clock.Advance(time.Hour)
+	_ = e.Evaluate(context.Background())
+	if len(e.Alerts()) != 0 {
+		t.Fatal("历史不足两个窗口时不应该告警")
+	}
+
+	for _, n := range []int{50, 50, 50} {
+		recordN(m, ErrTimeout, n)
+		clock.Advance(time.Hour)
+		_ = e.Evaluate(context.Background())
+	}
+
+	// 10 -> 50 增加400%，触发；50 -> 50 恢复；第三个窗口依然恢复
+	if len(notified) != 2 || notified[0].State != AlertFiring || notified[0].Value != 400 || notified[1].State != AlertResolved {
+		t.Fatalf("notified = %+v", notified)
+	}
+
+	// 持续触发时按重复间隔通知
+	notified = nil
+	for _, n := range []int{250, 1250, 6250} {
+		recordN(m, ErrTimeout, n)
+		clock.Advance(time.Hour)
+		_ = e.Evaluate(context.Background())
+	}
+	if len(notified) != 2 || notified[0].Severity != SeverityWarning {
+		t.Errorf("notified = %+v", notified)
+	}
+}
+
+// TestAlertSelector 测试按严重程度选择错误
+func TestAlertSelector(t *testing.T) {
+	m := NewMonitor()
+	recordN(m, ErrInternal, 3)
+	recordN(m, ErrValidation, 2)
+	recordN(m, ErrNotFound, 1)
+	snapshot := m.Snapshot()
+
+	tests := []struct {
+		selector AlertSelector
+		want     int64
+	}{
+		{AlertSelector{}, 6},
+		{AlertSelector{Code: ErrNotFound.Code}, 1},
+		{AlertSelector{Type: ErrTypeValidation}, 2},
+		{AlertSelector{Severity: SeverityWarning}, 5},
+		{AlertSelector{Severity: SeverityCritical}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.selector.count(snapshot); got != tt.want {
+			t.Errorf("%s: count = %d, want %d", tt.selector, got, tt.want)
+		}
+	}
+}
+
+// TestAlertEngine_AddRule 测试规则校验
+func TestAlertEngine_AddRule(t *testing.T) {
+	e := NewAlertEngine(NewMonitor())
+	if err := e.AddRule(AlertRule{Condition: RateAbove(1, time.Minute)}); err == nil {
+		t.Error("缺少名称应该返回错误")
+	}
+	if err := e.AddRule(AlertRule{Name: "a"}); err == nil {
+		t.Error("缺少条件应该返回错误")
+	}
+	if err := e.AddRule(AlertRule{Name: "a", Condition: RateAbove(1, time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.AddRule(AlertRule{Name: "a", Condition: RateAbove(1, time.Minute)}); err == nil {
+		t.Error("重复的名称应该返回错误")
+	}
+}
+
+// TestAlertEngine_NilMonitor 测试没有监控时评估不会panic，规则按零值计算
+func TestAlertEngine_NilMonitor(t *testing.T) {
+	e := NewAlertEngine(nil)
+	if err := e.AddRule(AlertRule{Name: "errors", Condition: RateAbove(0, time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Evaluate(context.Background()); err != nil {
+		t.Errorf("Evaluate() = %v", err)
+	}
+	if alerts := e.Alerts(); len(alerts) != 0 {
+		t.Errorf("Alerts() = %+v, want empty", alerts)
+	}
+}
+
+// TestWebhookNotifier 测试Webhook通知器
+func TestWebhookNotifier(t *testing.T) {
+	var received Alert
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			NewHandler(nil).WriteError(w, r, New(ErrExternal))
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	alert := Alert{Rule: "r", State: AlertFiring, Severity: SeverityError, Value: 7}
+	if err := WebhookNotifier(srv.URL+"/ok", nil).Notify(context.Background(), alert); err != nil {
+		t.Fatal(err)
+	}
+	if received.Rule != "r" || received.State != AlertFiring || received.Severity != SeverityError {
+		t.Errorf("received = %+v", received)
+	}
+
+	err := WebhookNotifier(srv.URL+"/fail", nil).Notify(context.Background(), alert)
+	var decoded Error
+	if !errors.As(err, &decoded) || decoded.Metadata()["remote_code"] != ErrExternal.Code {
+		t.Errorf("err = %v", err)
+	}
+}
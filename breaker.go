@@ -0,0 +1,390 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// BreakerState 熔断器状态
+type BreakerState int
+
+const (
+	// BreakerClosed 关闭状态，请求正常通过
+	BreakerClosed BreakerState = iota
+	// BreakerOpen 打开状态，请求直接失败
+	BreakerOpen
+	// BreakerHalfOpen 半开状态，允许少量探测请求通过
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// BreakerConfig 熔断器配置，零值字段使用默认值
+type BreakerConfig struct {
+	// 熔断器名称，通常为下游服务名
+	Name string
+	// 滑动窗口长度，默认10s，小于Buckets纳秒时按Buckets纳秒处理
+	Window time.Duration
+	// 滑动窗口的分桶数，默认10
+	Buckets int
+	// 窗口内触发熔断的最少请求数，默认20
+	MinRequests int64
+	// 触发熔断的失败比例，默认0.5
+	FailureRatio float64
+	// 打开状态持续的时间，之后进入半开状态，默认30s
+	OpenTimeout time.Duration
+	// 半开状态允许的探测请求数，全部成功后关闭熔断器，默认1
+	HalfOpenRequests int
+	// 计为失败的错误类型，默认为EXTERNAL和TIMEOUT，即只有下游故障会触发熔断，
+	// 自身的INTERNAL错误默认不计为失败，避免本地缺陷打开下游的熔断器
+	FailureTypes []ErrType
+	// 不计为失败的错误码，优先于FailureTypes
+	IgnoreCodes []string
+	// 自定义的失败判断，设置后FailureTypes和IgnoreCodes不再生效
+	IsFailure func(err error) bool
+	// 状态变化的回调，在熔断器的锁之外调用
+	OnStateChange func(name string, from, to BreakerState)
+	// 时钟，测试中可以注入假时钟
+	Clock Clock
+}
+
+// breakerBucket 滑动窗口中的一个分桶
+type breakerBucket struct {
+	start    time.Time
+	requests int64
+	failures int64
+}
+
+// CircuitBreaker 基于错误类型和错误码判断失败的熔断器
+// BAD_REQUEST、NOT_FOUND等客户端错误默认不计为失败，打开时返回ErrCircuitOpen错误
+type CircuitBreaker struct {
+	mu  sync.Mutex
+	cfg BreakerConfig
+	// 当前状态
+	state BreakerState
+	// 滑动窗口
+	buckets []breakerBucket
+	// 打开状态结束的时间
+	openUntil time.Time
+	// 半开状态下已放行的探测请求数
+	probes int
+	// 半开状态下成功的探测请求数
+	probeSuccesses int
+	// 状态的代数，每次状态切换加一，用于忽略切换前放行的请求的结果
+	generation uint64
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(cfg BreakerConfig) *CircuitBreaker {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.Buckets <= 0 {
+		cfg.Buckets = 10
+	}
+	// 每个分桶至少1ns，避免计算分桶时除以0
+	cfg.Window = max(cfg.Window, time.Duration(cfg.Buckets))
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 20
+	}
+	if cfg.FailureRatio <= 0 || cfg.FailureRatio > 1 {
+		cfg.FailureRatio = 0.5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = 1
+	}
+	if len(cfg.FailureTypes) == 0 {
+		cfg.FailureTypes = []ErrType{ErrTypeExternal, ErrTypeTimeout}
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = SystemClock{}
+	}
+
+	return &CircuitBreaker{
+		cfg:     cfg,
+		buckets: make([]breakerBucket, cfg.Buckets),
+	}
+}
+
+// Name 返回熔断器名称
+func (b *CircuitBreaker) Name() string {
+	return b.cfg.Name
+}
+
+// State 返回当前状态，打开状态超时后会转为半开状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	from, to := b.refresh(b.cfg.Clock.Now())
+	state := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return state
+}
+
+// Execute 通过熔断器执行fn，熔断器打开时不执行fn并返回ErrCircuitOpen错误，
+// 否则返回fn的错误，并根据失败判断更新熔断器的统计
+func (b *CircuitBreaker) Execute(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	fnErr := fn()
+	done(fnErr)
+	return fnErr
+}
+
+// Allow 判断请求是否可以通过，通过时返回的done必须在请求结束后调用一次并传入请求的错误，
+// 放行后熔断器的状态已经切换时，done传入的结果会被忽略，避免过期的结果关闭熔断器或被当作探测结果
+func (b *CircuitBreaker) Allow() (func(err error), Error) {
+	now := b.cfg.Clock.Now()
+
+	b.mu.Lock()
+	from, to := b.refresh(now)
+	switch b.state {
+	case BreakerOpen:
+		openUntil := b.openUntil
+		b.mu.Unlock()
+		b.notify(from, to)
+		return nil, b.openError(now, openUntil)
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenRequests {
+			openUntil := now
+			b.mu.Unlock()
+			b.notify(from, to)
+			return nil, b.openError(now, openUntil)
+		}
+		b.probes++
+	}
+	generation := b.generation
+	b.mu.Unlock()
+	b.notify(from, to)
+
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() {
+			b.report(generation, b.failure(err))
+		})
+	}, nil
+}
+
+// failure 判断错误是否计为失败
+func (b *CircuitBreaker) failure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if b.cfg.IsFailure != nil {
+		return b.cfg.IsFailure(err)
+	}
+
+	// 调用方主动取消不计为失败
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var e Error
+	if !errors.As(err, &e) {
+		return true
+	}
+
+	if slices.Contains(b.cfg.IgnoreCodes, e.Code()) {
+		return false
+	}
+
+	return slices.Contains(b.cfg.FailureTypes, e.Type())
+}
+
+// report 记录请求结果并更新状态，generation为放行请求时的状态代数，与当前代数不同时忽略结果
+func (b *CircuitBreaker) report(generation uint64, failed bool) {
+	now := b.cfg.Clock.Now()
+
+	b.mu.Lock()
+	from, to := b.refresh(now)
+	if generation != b.generation {
+		b.mu.Unlock()
+		b.notify(from, to)
+		return
+	}
+
+	switch b.state {
+	case BreakerHalfOpen:
+		if failed {
+			f, t := b.transition(BreakerOpen, now)
+			from, to = mergeTransition(from, to, f, t)
+			break
+		}
+		b.probeSuccesses++
+		if b.probeSuccesses >= b.cfg.HalfOpenRequests {
+			f, t := b.transition(BreakerClosed, now)
+			from, to = mergeTransition(from, to, f, t)
+		}
+	case BreakerClosed:
+		bucket := b.bucket(now)
+		bucket.requests++
+		if failed {
+			bucket.failures++
+		}
+
+		requests, failures := b.counts(now)
+		if requests >= b.cfg.MinRequests && float64(failures)/float64(requests) >= b.cfg.FailureRatio {
+			f, t := b.transition(BreakerOpen, now)
+			from, to = mergeTransition(from, to, f, t)
+		}
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// refresh 打开状态超时后转为半开状态，需要持有锁
+func (b *CircuitBreaker) refresh(now time.Time) (BreakerState, BreakerState) {
+	if b.state == BreakerOpen && !now.Before(b.openUntil) {
+		return b.transition(BreakerHalfOpen, now)
+	}
+
+	return b.state, b.state
+}
+
+// transition 切换状态并重置对应的统计，需要持有锁
+func (b *CircuitBreaker) transition(to BreakerState, now time.Time) (BreakerState, BreakerState) {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.probes = 0
+	b.probeSuccesses = 0
+
+	switch to {
+	case BreakerOpen:
+		b.openUntil = now.Add(b.cfg.OpenTimeout)
+	case BreakerClosed:
+		clear(b.buckets)
+	}
+
+	return from, to
+}
+
+// mergeTransition 合并一次操作中的连续状态变化
+func mergeTransition(from, to, nextFrom, nextTo BreakerState) (BreakerState, BreakerState) {
+	if from == to {
+		return nextFrom, nextTo
+	}
+
+	return from, nextTo
+}
+
+// notify 状态变化时调用回调
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+	if from != to && b.cfg.OnStateChange != nil {
+		b.cfg.OnStateChange(b.cfg.Name, from, to)
+	}
+}
+
+// bucket 返回当前时间所在的分桶，过期的分桶会被重置，需要持有锁
+func (b *CircuitBreaker) bucket(now time.Time) *breakerBucket {
+	width := b.cfg.Window / time.Duration(b.cfg.Buckets)
+	start := now.Truncate(width)
+	// 1970年之前或零值的时间得到负数，取模后再调整为非负的下标
+	n, buckets := start.UnixNano()/int64(width), int64(b.cfg.Buckets)
+	bucket := &b.buckets[((n%buckets)+buckets)%buckets]
+	if !bucket.start.Equal(start) {
+		*bucket = breakerBucket{start: start}
+	}
+
+	return bucket
+}
+
+// counts 返回滑动窗口内的请求数和失败数，需要持有锁
+func (b *CircuitBreaker) counts(now time.Time) (int64, int64) {
+	var requests, failures int64
+	for _, bucket := range b.buckets {
+		if now.Sub(bucket.start) < b.cfg.Window {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+
+	return requests, failures
+}
+
+// openError 创建熔断器打开的错误
+func (b *CircuitBreaker) openError(now, openUntil time.Time) Error {
+	err := FastNewf(ErrCircuitOpen, "circuit breaker %s is open", b.cfg.Name).
+		WithMetadata("breaker", b.cfg.Name).
+		WithMetadata("reopen_at", openUntil)
+
+	return WithRetryHint(err, RetryHint{RetryAfter: openUntil.Sub(now)})
+}
+
+// BreakerSnapshot 熔断器状态快照
+type BreakerSnapshot struct {
+	// 熔断器名称
+	Name string `json:"name"`
+	// 当前状态
+	State string `json:"state"`
+	// 滑动窗口内的请求数
+	Requests int64 `json:"requests"`
+	// 滑动窗口内的失败数
+	Failures int64 `json:"failures"`
+	// 打开状态结束的时间
+	OpenUntil time.Time `json:"openUntil,omitzero"`
+}
+
+// Snapshot 返回熔断器的状态快照
+func (b *CircuitBreaker) Snapshot() BreakerSnapshot {
+	now := b.cfg.Clock.Now()
+
+	b.mu.Lock()
+	from, to := b.refresh(now)
+	requests, failures := b.counts(now)
+	snapshot := BreakerSnapshot{
+		Name:     b.cfg.Name,
+		State:    b.state.String(),
+		Requests: requests,
+		Failures: failures,
+	}
+	if b.state == BreakerOpen {
+		snapshot.OpenUntil = b.openUntil
+	}
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	return snapshot
+}
+
+func (s BreakerSnapshot) String() string {
+	return fmt.Sprintf("%s(%s, %d/%d)", s.Name, s.State, s.Failures, s.Requests)
+}
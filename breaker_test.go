@@ -0,0 +1,251 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestBreaker 创建使用假时钟的熔断器，4次请求中一半失败即触发熔断
+func newTestBreaker(clock Clock, onChange func(name string, from, to BreakerState)) *CircuitBreaker {
+	return NewCircuitBreaker(BreakerConfig{
+		Name:          "payment",
+		MinRequests:   4,
+		FailureRatio:  0.5,
+		OpenTimeout:   5 * time.Second,
+		OnStateChange: onChange,
+		Clock:         clock,
+	})
+}
+
+// TestCircuitBreaker_Trip 测试熔断器的打开、半开和关闭
+func TestCircuitBreaker_Trip(t *testing.T) {
+	clock := newFakeClock()
+	var changes []BreakerState
+	b := newTestBreaker(clock, func(name string, from, to BreakerState) {
+		if name != "payment" {
+			t.Errorf("name = %q, want payment", name)
+		}
+		changes = append(changes, to)
+	})
+
+	// 客户端错误不计为失败
+	for range 4 {
+		_ = b.Execute(func() error { return FastNew(ErrBadRequest) })
+		_ = b.Execute(func() error { return FastNew(ErrNotFound) })
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("客户端错误不应该触发熔断, state = %s", b.State())
+	}
+
+	for range 8 {
+		_ = b.Execute(func() error { return FastNew(ErrTimeout) })
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("state = %s, want open", b.State())
+	}
+
+	called := false
+	err := b.Execute(func() error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Error("熔断器打开时不应该执行fn")
+	}
+
+	var e Error
+	if !errors.As(err, &e) || e.Code() != ErrCircuitOpen.Code {
+		t.Fatalf("err = %v, want CIRCUIT_OPEN", err)
+	}
+	if e.Type() != ErrTypeExternal || e.HttpStatus() != http.StatusServiceUnavailable {
+		t.Errorf("type = %s, status = %d", e.Type(), e.HttpStatus())
+	}
+	if e.Metadata()["breaker"] != "payment" {
+		t.Errorf("breaker metadata = %v", e.Metadata()["breaker"])
+	}
+	if d, ok := RetryAfter(err); !ok || d != 5*time.Second {
+		t.Errorf("RetryAfter() = %v, %v, want 5s", d, ok)
+	}
+
+	// 超时后进入半开状态，探测失败重新打开
+	clock.Advance(5 * time.Second)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+	_ = b.Execute(func() error { return FastNew(ErrExternal) })
+	if b.State() != BreakerOpen {
+		t.Fatalf("探测失败后 state = %s, want open", b.State())
+	}
+
+	// 探测成功后关闭
+	clock.Advance(5 * time.Second)
+	if err := b.Execute(func() error { return nil }); err != nil {
+		t.Fatalf("探测请求 err = %v", err)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("state = %s, want closed", b.State())
+	}
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v, want %v", changes, want)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("changes[%d] = %s, want %s", i, changes[i], want[i])
+		}
+	}
+}
+
+// TestCircuitBreaker_HalfOpenLimit 测试半开状态只放行有限的探测请求
+func TestCircuitBreaker_HalfOpenLimit(t *testing.T) {
+	clock := newFakeClock()
+	b := newTestBreaker(clock, nil)
+	for range 4 {
+		_ = b.Execute(func() error { return errors.New("connection reset") })
+	}
+	clock.Advance(5 * time.Second)
+
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("第一次探测应该放行, err = %v", err)
+	}
+	if _, err := b.Allow(); err == nil || err.Code() != ErrCircuitOpen.Code {
+		t.Errorf("探测请求用完后 err = %v, want CIRCUIT_OPEN", err)
+	}
+
+	// 调用方取消不计为失败
+	done(context.Canceled)
+	if b.State() != BreakerClosed {
+		t.Errorf("state = %s, want closed", b.State())
+	}
+}
+
+// TestCircuitBreaker_Window 测试滑动窗口外的失败不再计数
+func TestCircuitBreaker_Window(t *testing.T) {
+	clock := newFakeClock()
+	b := newTestBreaker(clock, nil)
+	for range 3 {
+		_ = b.Execute(func() error { return FastNew(ErrExternal) })
+	}
+	if s := b.Snapshot(); s.Requests != 3 || s.Failures != 3 {
+		t.Errorf("snapshot = %+v", s)
+	}
+
+	clock.Advance(11 * time.Second)
+	_ = b.Execute(func() error { return FastNew(ErrExternal) })
+	if b.State() != BreakerClosed {
+		t.Errorf("窗口外的失败不应该计数, state = %s", b.State())
+	}
+	if s := b.Snapshot(); s.Requests != 1 {
+		t.Errorf("requests = %d, want 1", s.Requests)
+	}
+}
+
+// TestCircuitBreaker_StaleDone 测试熔断前放行的请求的结果不会影响之后的状态
+func TestCircuitBreaker_StaleDone(t *testing.T) {
+	clock := newFakeClock()
+	b := newTestBreaker(clock, nil)
+
+	stale, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow() err = %v", err)
+	}
+	for range 4 {
+		_ = b.Execute(func() error { return FastNew(ErrTimeout) })
+	}
+	clock.Advance(5 * time.Second)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state = %s, want half-open", b.State())
+	}
+
+	// 熔断前放行的请求成功返回，不应该关闭熔断器，也不应该占用探测结果
+	stale(nil)
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("过期的结果不应该改变状态, state = %s", b.State())
+	}
+
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("探测请求应该放行, err = %v", err)
+	}
+	probe(FastNew(ErrExternal))
+	if b.State() != BreakerOpen {
+		t.Errorf("探测失败后 state = %s, want open", b.State())
+	}
+}
+
+// TestCircuitBreaker_DefaultFailureTypes 测试自身的INTERNAL错误默认不计为失败
+func TestCircuitBreaker_DefaultFailureTypes(t *testing.T) {
+	b := newTestBreaker(newFakeClock(), nil)
+	for range 8 {
+		_ = b.Execute(func() error { return FastNew(ErrInternal) })
+	}
+	if b.State() != BreakerClosed {
+		t.Errorf("INTERNAL错误不应该触发熔断, state = %s", b.State())
+	}
+}
+
+// TestMonitor 测试监控统计和熔断器状态
+func TestMonitor(t *testing.T) {
+	clock := newFakeClock()
+	m := NewMonitor(WithMonitorClock(clock))
+	b := newTestBreaker(clock, nil)
+	m.RegisterBreaker(b)
+	m.RegisterBreaker(NewCircuitBreaker(BreakerConfig{Name: "inventory", Clock: clock}))
+
+	for range 4 {
+		_ = b.Execute(func() error { return FastNew(ErrTimeout) })
+	}
+
+	h := NewHandler(nil, WithMonitor(m))
+	err := b.Execute(func() error { return nil })
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pay", nil), err)
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/pay", nil), FastNew(ErrNotFound))
+	m.Record(nil)
+
+	s := m.Snapshot()
+	if s.Total != 2 || s.ByCode[ErrCircuitOpen.Code] != 1 || s.ByType[ErrTypeNotFound] != 1 ||
+		s.ByStatus[http.StatusServiceUnavailable] != 1 {
+		t.Errorf("snapshot = %+v", s)
+	}
+	if len(s.Breakers) != 2 || s.Breakers[0].Name != "inventory" || s.Breakers[1].State != "open" {
+		t.Errorf("breakers = %v", s.Breakers)
+	}
+	if !s.Breakers[1].OpenUntil.Equal(clock.Now().Add(5 * time.Second)) {
+		t.Errorf("openUntil = %v", s.Breakers[1].OpenUntil)
+	}
+}
+
+// TestCircuitBreaker_BucketEdgeCases 测试过小的窗口以及1970年之前和零值的时间不会panic
+func TestCircuitBreaker_BucketEdgeCases(t *testing.T) {
+	for _, now := range []time.Time{{}, time.Date(1960, 1, 1, 0, 0, 0, 1, time.UTC), time.Now()} {
+		clock := &fakeClock{now: now}
+		b := NewCircuitBreaker(BreakerConfig{Window: 5, Buckets: 10, Clock: clock})
+		for range 3 {
+			_ = b.Execute(func() error { return FastNew(ErrTimeout) })
+			clock.Advance(time.Nanosecond)
+		}
+		if s := b.Snapshot(); s.State != BreakerClosed.String() {
+			t.Errorf("%v: state = %s", now, s.State)
+		}
+	}
+}
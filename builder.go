@@ -8,6 +8,14 @@ type Builder struct {
 	code *ErrCode
 	// 错误详情
 	message string
+	// 面向用户的安全信息
+	publicMessage string
+	// 面向开发者的内部详情
+	detail string
+	// 是否可重试，覆盖错误码的设置
+	retryable Retryability
+	// 严重程度，覆盖错误码的设置
+	severity Severity
 	// 原始错误
 	cause error
 	// 元数据信息
@@ -37,6 +45,34 @@ func (b *Builder) WithMessage(message string) *Builder {
 	return b
 }
 
+// WithPublicMessage 设置面向用户的安全信息，生产环境中内部错误只会返回该信息
+func (b *Builder) WithPublicMessage(message string) *Builder {
+	b.publicMessage = message
+	return b
+}
+
+// WithDetail 设置面向开发者的内部详情，只会被记录到日志中
+func (b *Builder) WithDetail(detail string) *Builder {
+	b.detail = detail
+	return b
+}
+
+// WithRetryable 指定错误是否可重试，覆盖错误码和错误类型的默认判断
+func (b *Builder) WithRetryable(retryable bool) *Builder {
+	if retryable {
+		b.retryable = RetryEnabled
+	} else {
+		b.retryable = RetryDisabled
+	}
+	return b
+}
+
+// WithSeverity 指定错误的严重程度，覆盖错误码和错误类型的默认设置
+func (b *Builder) WithSeverity(severity Severity) *Builder {
+	b.severity = severity
+	return b
+}
+
 func (b *Builder) WithCause(cause error) *Builder {
 	b.cause = cause
 	return b
@@ -66,15 +102,35 @@ func (b *Builder) Build() Error {
 	impl := acquireError()
 	impl.code = b.code.Code
 	impl.message = b.message
+	impl.publicMessage = b.publicMessage
+	if impl.publicMessage == "" {
+		impl.publicMessage = b.code.publicMessage()
+	}
+	impl.messageKey = b.code.messageKey()
+	impl.detail = b.detail
 	impl.httpStatus = b.code.HttpStatus
 	impl.errType = b.code.Type
+	impl.retryable = b.retryable
+	if impl.retryable == RetryByType {
+		impl.retryable = b.code.Retryable
+	}
+	impl.severity = b.severity
+	if impl.severity == SeverityByType {
+		impl.severity = b.code.Severity
+	}
 	impl.timestamp = time.Now().UTC()
 	impl.cause = b.cause
 	impl.metadata = b.metadata
 
 	// 不开启快速模式，则记录堆栈信息
 	if !b.fastMode {
-		impl.stackTrace = captureStackTrace(2)
+		impl.stackTrace = captureStackTrace(1)
+	}
+
+	if impl.cause != nil {
+		fireWrap(impl.cause, impl)
+	} else {
+		fireCreate(impl)
 	}
 
 	return impl
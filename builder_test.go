@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 )
@@ -312,3 +313,42 @@ func BenchmarkBuilderVsDirect(b *testing.B) {
 		}
 	})
 }
+
+func TestBuilder_PublicMessageAndDetail(t *testing.T) {
+	err := NewBuilder().
+		WithCode(ErrInternal).
+		WithMessage("db conn failed").
+		WithDetail("dial tcp 10.0.0.1:3306: connection refused").
+		Build()
+
+	if err.PublicMessage() != ErrInternalMessage {
+		t.Errorf("期望公开消息 %s，但得到了 %s", ErrInternalMessage, err.PublicMessage())
+	}
+	if err.Detail() != "dial tcp 10.0.0.1:3306: connection refused" {
+		t.Errorf("期望内部详情，但得到了 %s", err.Detail())
+	}
+
+	err = NewBuilder().
+		WithCode(ErrInternal).
+		WithPublicMessage("服务繁忙，请稍后重试").
+		Build()
+	if err.PublicMessage() != "服务繁忙，请稍后重试" {
+		t.Errorf("期望自定义公开消息，但得到了 %s", err.PublicMessage())
+	}
+	if err.Detail() != err.Message() {
+		t.Errorf("未设置详情时应该返回消息，但得到了 %s", err.Detail())
+	}
+}
+
+// TestBuilder_StackTraceTopFrame 测试Build记录的堆栈从调用Build的函数开始
+func TestBuilder_StackTraceTopFrame(t *testing.T) {
+	err := NewBuilder().WithCode(ErrInternal).Build()
+
+	lines := strings.Split(err.StackTrace(), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("堆栈信息为空: %q", err.StackTrace())
+	}
+	if top := strings.TrimSpace(lines[1]); !strings.HasSuffix(top, ".TestBuilder_StackTraceTopFrame") {
+		t.Errorf("栈顶帧 = %s, want TestBuilder_StackTraceTopFrame", top)
+	}
+}
@@ -0,0 +1,337 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// MetadataClassifiedBy 自动分类时命中的规则名称在元数据中的键名
+const MetadataClassifiedBy = "classified_by"
+
+// ClassifyRule 错误分类规则，Match命中时使用Code包装错误
+type ClassifyRule struct {
+	// 规则名称，记录在包装后错误的元数据中
+	Name string
+	// 命中后使用的错误码
+	Code *ErrCode
+	// 判断错误是否命中规则
+	Match func(err error) bool
+}
+
+// IsRule 创建通过errors.Is匹配的规则，命中任意一个target即可，target为nil时panic，
+// nil永远不会被errors.Is匹配，通常是哨兵错误还没有初始化
+func IsRule(code *ErrCode, targets ...error) ClassifyRule {
+	names := make([]string, 0, len(targets))
+	for i, target := range targets {
+		if target == nil {
+			panic(fmt.Sprintf("errors: IsRule target %d is nil", i))
+		}
+		names = append(names, target.Error())
+	}
+
+	return ClassifyRule{
+		Name: "is:" + strings.Join(names, "|"),
+		Code: code,
+		Match: func(err error) bool {
+			for _, target := range targets {
+				if errors.Is(err, target) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// AsRule 创建通过errors.As匹配的规则，match不为空时还需要满足match
+func AsRule[T error](code *ErrCode, match func(T) bool) ClassifyRule {
+	return ClassifyRule{
+		Name: "as:" + reflect.TypeFor[T]().String(),
+		Code: code,
+		Match: func(err error) bool {
+			var target T
+			if !errors.As(err, &target) {
+				return false
+			}
+			return match == nil || match(target)
+		},
+	}
+}
+
+// PredicateRule 创建自定义判断的规则
+func PredicateRule(name string, code *ErrCode, match func(err error) bool) ClassifyRule {
+	return ClassifyRule{Name: name, Code: code, Match: match}
+}
+
+// SQLStateRule 创建按SQLSTATE匹配的规则，适用于实现了SQLState() string的驱动错误，
+// 如pgconn.PgError和pq.Error。两位的state匹配整个类别，如"23"匹配所有完整性约束错误
+func SQLStateRule(code *ErrCode, states ...string) ClassifyRule {
+	return ClassifyRule{
+		Name: "sqlstate:" + strings.Join(states, "|"),
+		Code: code,
+		Match: func(err error) bool {
+			var stater interface{ SQLState() string }
+			if !errors.As(err, &stater) {
+				return false
+			}
+
+			state := stater.SQLState()
+			for _, s := range states {
+				if state == s || (len(s) == 2 && strings.HasPrefix(state, s)) {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// ErrorNumberRule 创建按驱动错误号匹配的规则，适用于带有整数Number字段或Number()方法的
+// 驱动错误，如mysql.MySQLError，不需要引入驱动包
+func ErrorNumberRule(code *ErrCode, numbers ...int) ClassifyRule {
+	strs := make([]string, 0, len(numbers))
+	for _, number := range numbers {
+		strs = append(strs, fmt.Sprint(number))
+	}
+
+	return ClassifyRule{
+		Name: "number:" + strings.Join(strs, "|"),
+		Code: code,
+		Match: func(err error) bool {
+			return walkErrors(err, func(e error) bool {
+				number, ok := errorNumber(e)
+				return ok && slices.Contains(numbers, number)
+			})
+		},
+	}
+}
+
+// errorNumber 读取驱动错误的错误号
+func errorNumber(err error) (int, bool) {
+	if numberer, ok := err.(interface{ Number() int }); ok {
+		return numberer.Number(), true
+	}
+
+	v := reflect.ValueOf(err)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return 0, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return 0, false
+	}
+
+	field := v.FieldByName("Number")
+	switch {
+	case !field.IsValid():
+		return 0, false
+	case field.CanInt():
+		return int(field.Int()), true
+	case field.CanUint():
+		return int(field.Uint()), true
+	}
+
+	return 0, false
+}
+
+// walkErrors 深度优先遍历错误链，包括Unwrap() []error的分支，fn返回true时停止
+func walkErrors(err error, fn func(error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if fn(err) {
+		return true
+	}
+
+	switch x := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, e := range x.Unwrap() {
+			if walkErrors(e, fn) {
+				return true
+			}
+		}
+	case interface{ Unwrap() error }:
+		return walkErrors(x.Unwrap(), fn)
+	}
+
+	return false
+}
+
+// StdlibRules 返回标准库错误的内置规则
+//   - context.DeadlineExceeded、os.ErrDeadlineExceeded和超时的net.Error -> ErrTimeout
+//   - context.Canceled -> ErrCanceled，客户端断开或调用方取消，不计入5xx统计和熔断失败
+//   - sql.ErrNoRows、os.ErrNotExist -> ErrNotFound
+//   - os.ErrPermission -> ErrForbidden
+//   - syscall.ECONNREFUSED、syscall.ECONNRESET -> ErrExternal
+func StdlibRules() []ClassifyRule {
+	return []ClassifyRule{
+		IsRule(ErrTimeout, context.DeadlineExceeded, os.ErrDeadlineExceeded),
+		AsRule(ErrTimeout, func(err net.Error) bool { return err.Timeout() }),
+		IsRule(ErrCanceled, context.Canceled),
+		IsRule(ErrNotFound, sql.ErrNoRows),
+		IsRule(ErrNotFound, os.ErrNotExist),
+		IsRule(ErrForbidden, os.ErrPermission),
+		IsRule(ErrExternal, syscall.ECONNREFUSED, syscall.ECONNRESET),
+	}
+}
+
+// Classifier 按规则表把标准error映射为ErrCode，规则按顺序匹配，第一个命中的规则生效
+type Classifier struct {
+	mu    sync.RWMutex
+	rules []ClassifyRule
+	// 没有规则命中时使用的错误码
+	fallback *ErrCode
+}
+
+// NewClassifier 创建分类器，fallback为空时使用ErrInternal
+func NewClassifier(fallback *ErrCode, rules ...ClassifyRule) *Classifier {
+	if fallback == nil {
+		fallback = ErrInternal
+	}
+
+	return &Classifier{
+		rules:    slices.Clone(rules),
+		fallback: fallback,
+	}
+}
+
+// DefaultClassifier 包含标准库内置规则的默认分类器，WrapAuto和中间件使用该分类器
+var DefaultClassifier = NewClassifier(ErrInternal, StdlibRules()...)
+
+// Add 在规则表末尾追加规则，优先级低于已有规则
+func (c *Classifier) Add(rules ...ClassifyRule) *Classifier {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rules = append(c.rules, rules...)
+	return c
+}
+
+// Prepend 在规则表开头插入规则，优先级高于已有规则
+func (c *Classifier) Prepend(rules ...ClassifyRule) *Classifier {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rules = append(slices.Clone(rules), c.rules...)
+	return c
+}
+
+// Rules 返回规则表的副本
+func (c *Classifier) Rules() []ClassifyRule {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.rules)
+}
+
+// Match 返回第一个命中的规则
+func (c *Classifier) Match(err error) (ClassifyRule, bool) {
+	if err == nil {
+		return ClassifyRule{}, false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, rule := range c.rules {
+		if rule.Match != nil && rule.Match(err) {
+			return rule, true
+		}
+	}
+
+	return ClassifyRule{}, false
+}
+
+// Classify 返回错误对应的错误码，已经是Error时返回nil，没有规则命中时返回fallback
+func (c *Classifier) Classify(err error) *ErrCode {
+	_, code, _ := c.classify(err)
+	return code
+}
+
+// Wrap 按规则表包装错误并携带调用堆栈信息，已经是Error时直接返回
+func (c *Classifier) Wrap(err error) Error {
+	rule, code, customErr := c.classify(err)
+	if code == nil {
+		return customErr
+	}
+
+	return markClassified(err, wrap(true, err, code), rule)
+}
+
+// FastWrap 按规则表包装错误，不携带调用堆栈信息，已经是Error时直接返回
+func (c *Classifier) FastWrap(err error) Error {
+	rule, code, customErr := c.classify(err)
+	if code == nil {
+		return customErr
+	}
+
+	return markClassified(err, wrap(false, err, code), rule)
+}
+
+// classify 返回命中的规则和错误码，err为nil或已经是Error时错误码为nil，并返回已有的Error
+func (c *Classifier) classify(err error) (ClassifyRule, *ErrCode, Error) {
+	if err == nil {
+		return ClassifyRule{}, nil, nil
+	}
+
+	var customErr Error
+	if errors.As(err, &customErr) {
+		return ClassifyRule{}, nil, customErr
+	}
+
+	if rule, ok := c.Match(err); ok {
+		return rule, rule.Code, nil
+	}
+
+	return ClassifyRule{}, c.fallback, nil
+}
+
+// markClassified 记录命中的规则名称，之后再触发钩子，钩子中可以看到分类的规则
+func markClassified(cause error, wrapped *ErrorImpl, rule ClassifyRule) Error {
+	if rule.Name != "" {
+		wrapped.WithMetadata(MetadataClassifiedBy, rule.Name)
+	}
+
+	fireWrap(cause, wrapped)
+	return wrapped
+}
+
+// WrapAuto 使用DefaultClassifier选择错误码并包装错误，携带调用堆栈信息
+func WrapAuto(err error) Error {
+	rule, code, customErr := DefaultClassifier.classify(err)
+	if code == nil {
+		return customErr
+	}
+
+	return markClassified(err, wrap(true, err, code), rule)
+}
+
+// FastWrapAuto 使用DefaultClassifier选择错误码并包装错误，不携带调用堆栈信息
+func FastWrapAuto(err error) Error {
+	return DefaultClassifier.FastWrap(err)
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// mysqlError 模拟mysql.MySQLError，错误号为导出字段
+type mysqlError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *mysqlError) Error() string {
+	return fmt.Sprintf("Error %d: %s", e.Number, e.Message)
+}
+
+// pgError 模拟pgconn.PgError，通过SQLState()返回错误码
+type pgError struct {
+	Code string
+}
+
+func (e *pgError) Error() string {
+	return "pg error " + e.Code
+}
+
+func (e *pgError) SQLState() string {
+	return e.Code
+}
+
+// timeoutError 模拟超时的net.Error
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+// TestWrapAuto 测试标准库错误的内置映射
+func TestWrapAuto(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *ErrCode
+	}{
+		{name: "sql.ErrNoRows", err: fmt.Errorf("query user: %w", sql.ErrNoRows), want: ErrNotFound},
+		{name: "deadline", err: context.DeadlineExceeded, want: ErrTimeout},
+		{name: "canceled", err: fmt.Errorf("read body: %w", context.Canceled), want: ErrCanceled},
+		{name: "not exist", err: &os.PathError{Op: "open", Path: "/x", Err: os.ErrNotExist}, want: ErrNotFound},
+		{name: "permission", err: &os.PathError{Op: "open", Path: "/x", Err: os.ErrPermission}, want: ErrForbidden},
+		{name: "net timeout", err: &net.OpError{Op: "read", Net: "tcp", Err: timeoutError{}}, want: ErrTimeout},
+		{
+			name: "connection refused",
+			err:  &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)},
+			want: ErrExternal,
+		},
+		{name: "unknown", err: errors.New("boom"), want: ErrInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := WrapAuto(tt.err)
+			if err.Code() != tt.want.Code || err.HttpStatus() != tt.want.HttpStatus {
+				t.Errorf("WrapAuto() = %s/%d, want %s/%d", err.Code(), err.HttpStatus(), tt.want.Code, tt.want.HttpStatus)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("包装后的错误应该保留原始错误")
+			}
+			if err.StackTrace() == "" || !strings.Contains(err.StackTrace(), "classify_test.go") {
+				t.Errorf("堆栈应该从调用方开始: %s", err.StackTrace())
+			}
+		})
+	}
+
+	if WrapAuto(nil) != nil {
+		t.Error("WrapAuto(nil) 应该返回nil")
+	}
+
+	original := FastNew(ErrConflict)
+	if WrapAuto(fmt.Errorf("ctx: %w", original)) != original {
+		t.Error("已经是Error时应该直接返回")
+	}
+
+	err := FastWrapAuto(sql.ErrNoRows)
+	if err.StackTrace() != "" || err.Metadata()[MetadataClassifiedBy] != "is:"+sql.ErrNoRows.Error() {
+		t.Errorf("FastWrapAuto() stack = %q, metadata = %v", err.StackTrace(), err.Metadata())
+	}
+}
+
+// TestClassifier_DriverRules 测试不引入驱动的错误号和SQLSTATE规则
+func TestClassifier_DriverRules(t *testing.T) {
+	c := NewClassifier(nil, StdlibRules()...).Add(
+		ErrorNumberRule(ErrConflict, 1062),
+		ErrorNumberRule(ErrTimeout, 1205, 3024),
+		SQLStateRule(ErrConflict, "23505"),
+		SQLStateRule(ErrExternal, "08"),
+	)
+
+	tests := []struct {
+		name string
+		err  error
+		want *ErrCode
+	}{
+		{name: "mysql duplicate", err: fmt.Errorf("insert: %w", &mysqlError{Number: 1062}), want: ErrConflict},
+		{name: "mysql lock wait", err: &mysqlError{Number: 1205}, want: ErrTimeout},
+		{name: "mysql other", err: &mysqlError{Number: 1146}, want: ErrInternal},
+		{name: "pg unique", err: &pgError{Code: "23505"}, want: ErrConflict},
+		{name: "pg connection class", err: &pgError{Code: "08006"}, want: ErrExternal},
+		{name: "pg other", err: &pgError{Code: "42P01"}, want: ErrInternal},
+		{name: "joined", err: errors.Join(errors.New("tx"), &mysqlError{Number: 1062}), want: ErrConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Classify(tt.err); got != tt.want {
+				t.Errorf("Classify() = %s, want %s", got.Code, tt.want.Code)
+			}
+		})
+	}
+}
+
+// TestClassifier_Order 测试规则按顺序匹配
+func TestClassifier_Order(t *testing.T) {
+	errQuota := errors.New("quota exceeded")
+	c := NewClassifier(ErrBadRequest, IsRule(ErrInternal, errQuota))
+	if got := c.Classify(errors.New("x")); got != ErrBadRequest {
+		t.Errorf("fallback = %s, want %s", got.Code, ErrBadRequest.Code)
+	}
+
+	c.Prepend(PredicateRule("quota", ErrRateLimit, func(err error) bool {
+		return strings.Contains(err.Error(), "quota")
+	}))
+	if got := c.Classify(errQuota); got != ErrRateLimit {
+		t.Errorf("Classify() = %s, want %s", got.Code, ErrRateLimit.Code)
+	}
+	if rule, ok := c.Match(errQuota); !ok || rule.Name != "quota" {
+		t.Errorf("Match() = %+v, %v", rule, ok)
+	}
+	if len(c.Rules()) != 2 {
+		t.Errorf("Rules() = %d, want 2", len(c.Rules()))
+	}
+}
+
+// TestIsRule_NilTarget 测试nil的target在创建规则时给出明确的panic信息
+func TestIsRule_NilTarget(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "errors: IsRule target 1 is nil" {
+			t.Errorf("recover() = %v", r)
+		}
+	}()
+
+	IsRule(ErrNotFound, sql.ErrNoRows, nil)
+	t.Error("nil的target应该panic")
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxErrorBodySize 解析下游错误响应时读取的最大字节数
+const maxErrorBodySize = 1 << 20
+
+// RemoteError 下游服务返回的错误，作为重建后Error的原始错误
+type RemoteError struct {
+	// 下游服务的错误码
+	Code string
+	// 下游服务的错误类型
+	Type ErrType
+	// 下游服务的错误信息
+	Message string
+	// 下游服务的HTTP状态码
+	Status int
+	// 下游服务的请求ID
+	RequestID string
+	// 下游服务地址
+	Host string
+	// 下游服务返回的详情信息
+	Details map[string]any
+}
+
+func (e *RemoteError) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("remote %s returned %d %s: %s", e.Host, e.Status, e.Code, e.Message)
+	}
+
+	return fmt.Sprintf("remote %s returned %d: %s", e.Host, e.Status, e.Message)
+}
+
+// problemDetails RFC 7807 Problem Details响应结构
+type problemDetails struct {
+	Type      string         `json:"type"`
+	Title     string         `json:"title"`
+	Status    int            `json:"status"`
+	Detail    string         `json:"detail"`
+	Instance  string         `json:"instance"`
+	Code      string         `json:"code"`
+	RequestID string         `json:"requestId"`
+	Details   map[string]any `json:"details"`
+}
+
+// DecodeResponse 将下游服务的错误响应还原为Error，状态码小于400时返回nil
+// 支持本库的ErrorResponse结构和RFC 7807 Problem Details，其它响应体作为纯文本处理。
+// 还原后的Error类型为EXTERNAL，原始错误为*RemoteError，元数据中记录了下游地址、
+// 状态码、错误码和请求ID。读取的响应体会被重新放回resp.Body，调用方仍可读取
+func DecodeResponse(resp *http.Response) Error {
+	if resp == nil || resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+
+	remote := &RemoteError{
+		Status:    resp.StatusCode,
+		RequestID: resp.Header.Get(HeaderRequestID),
+	}
+	if resp.Request != nil && resp.Request.URL != nil {
+		remote.Host = resp.Request.URL.Host
+	}
+
+	var body []byte
+	if resp.Body != nil {
+		body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		_ = resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	decodeRemoteBody(remote, resp.Header.Get("Content-Type"), body)
+	if remote.Message == "" {
+		remote.Message = http.StatusText(resp.StatusCode)
+	}
+
+	err := newExternalError(remote)
+	if hint := readRetryHeaders(resp.Header, time.Now()); !hint.IsZero() {
+		WithRetryHint(err, hint)
+	}
+
+	return err
+}
+
+// decodeRemoteBody 根据响应类型解析下游错误响应体
+func decodeRemoteBody(remote *RemoteError, contentType string, body []byte) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/problem+json" {
+		decodeProblemDetails(remote, body)
+		return
+	}
+
+	if body[0] == '{' {
+		var envelope ErrorResponse
+		if err := json.Unmarshal(body, &envelope); err == nil && envelope.Code != "" {
+			remote.Code = envelope.Code
+			remote.Type = envelope.Type
+			remote.Message = envelope.Message
+			remote.Details = envelope.Details
+			if envelope.RequestID != "" {
+				remote.RequestID = envelope.RequestID
+			}
+			return
+		}
+
+		if decodeProblemDetails(remote, body) {
+			return
+		}
+	}
+
+	remote.Message = strings.TrimSpace(string(body))
+}
+
+// decodeProblemDetails 解析Problem Details响应体，解析失败时返回false
+func decodeProblemDetails(remote *RemoteError, body []byte) bool {
+	var problem problemDetails
+	if err := json.Unmarshal(body, &problem); err != nil {
+		return false
+	}
+
+	if problem.Title == "" && problem.Detail == "" && problem.Code == "" {
+		return false
+	}
+
+	remote.Code = problem.Code
+	remote.Message = problem.Detail
+	if remote.Message == "" {
+		remote.Message = problem.Title
+	}
+	remote.Details = problem.Details
+	if problem.RequestID != "" {
+		remote.RequestID = problem.RequestID
+	}
+
+	return true
+}
+
+// newExternalError 根据下游错误创建EXTERNAL类型的Error
+func newExternalError(remote *RemoteError) Error {
+	b := NewBuilder().
+		WithCode(ErrExternal).
+		WithFastMode().
+		WithMessage(fmt.Sprintf("upstream %s returned %d: %s", remote.Host, remote.Status, remote.Message)).
+		WithCause(remote).
+		WithMetadata("upstream_host", remote.Host).
+		WithMetadata("upstream_status", remote.Status)
+
+	// 在Build之前写入所有元数据，钩子触发时可以看到完整的下游信息
+	if remote.Code != "" {
+		b.WithMetadata("remote_code", remote.Code)
+	}
+	if remote.RequestID != "" {
+		b.WithMetadata("remote_request_id", remote.RequestID)
+	}
+
+	return b.Build()
+}
+
+// Do 使用client发送请求，下游返回错误响应时关闭响应体并返回DecodeResponse还原的Error，
+// 发送失败时返回client的原始错误，client为nil时使用http.DefaultClient。
+// 不以http.RoundTripper的方式提供，RoundTripper不应该解释响应，也不能同时返回响应和错误
+func Do(client *http.Client, req *http.Request) (*http.Response, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if decoded := DecodeResponse(resp); decoded != nil {
+		_ = resp.Body.Close()
+		return nil, decoded
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+// TestDecodeResponse 测试下游错误响应的还原
+func TestDecodeResponse(t *testing.T) {
+	downstream := NewHandler(nil)
+
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		wantRemote    string
+		wantRequestID string
+		wantMessage   string
+		wantStatus    int
+	}{
+		{
+			name: "本库的错误响应",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				downstream.WriteError(w, r, Newf(ErrNotFound, "user 1 not found"))
+			},
+			wantRemote:    ErrNotFound.Code,
+			wantRequestID: "req-b",
+			wantMessage:   "user 1 not found",
+			wantStatus:    http.StatusNotFound,
+		},
+		{
+			name: "Problem Details响应",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.Header().Set(HeaderRequestID, "req-p")
+				w.WriteHeader(http.StatusConflict)
+				_, _ = io.WriteString(w, `{"type":"about:blank","title":"Conflict","status":409,"detail":"order locked","code":"ORDER_LOCKED"}`)
+			},
+			wantRemote:    "ORDER_LOCKED",
+			wantRequestID: "req-p",
+			wantMessage:   "order locked",
+			wantStatus:    http.StatusConflict,
+		},
+		{
+			name: "纯文本响应",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "bad gateway", http.StatusBadGateway)
+			},
+			wantMessage: "bad gateway",
+			wantStatus:  http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+			req.Header.Set(HeaderRequestID, "req-b")
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+
+			decoded := DecodeResponse(resp)
+			if decoded == nil {
+				t.Fatal("期望得到错误，但得到了nil")
+			}
+			if decoded.Type() != ErrTypeExternal {
+				t.Errorf("Type() = %v, want %v", decoded.Type(), ErrTypeExternal)
+			}
+
+			var remote *RemoteError
+			if !errors.As(decoded, &remote) {
+				t.Fatal("原始错误应该为*RemoteError")
+			}
+			if remote.Code != tt.wantRemote {
+				t.Errorf("remote.Code = %s, want %s", remote.Code, tt.wantRemote)
+			}
+			if remote.Message != tt.wantMessage {
+				t.Errorf("remote.Message = %s, want %s", remote.Message, tt.wantMessage)
+			}
+
+			u, _ := url.Parse(srv.URL)
+			metadata := decoded.Metadata()
+			if metadata["upstream_host"] != u.Host {
+				t.Errorf("upstream_host = %v, want %v", metadata["upstream_host"], u.Host)
+			}
+			if metadata["upstream_status"] != tt.wantStatus {
+				t.Errorf("upstream_status = %v, want %v", metadata["upstream_status"], tt.wantStatus)
+			}
+			if tt.wantRequestID != "" && metadata["remote_request_id"] != tt.wantRequestID {
+				t.Errorf("remote_request_id = %v, want %v", metadata["remote_request_id"], tt.wantRequestID)
+			}
+		})
+	}
+}
+
+// TestDo 测试发送请求并还原错误响应
+func TestDo(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ok" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		NewHandler(nil).WriteError(w, r, New(ErrRateLimit))
+	}))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ok", nil)
+	resp, err := Do(srv.Client(), req)
+	if err != nil {
+		t.Fatalf("成功响应不应该返回错误: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	req, _ = http.NewRequest(http.MethodGet, srv.URL+"/limited", nil)
+	resp, err = Do(srv.Client(), req)
+	var decoded Error
+	if resp != nil || !errors.As(err, &decoded) {
+		t.Fatalf("期望得到Error，但得到了 %v", err)
+	}
+	if decoded.Metadata()["remote_code"] != ErrRateLimit.Code {
+		t.Errorf("remote_code = %v, want %v", decoded.Metadata()["remote_code"], ErrRateLimit.Code)
+	}
+
+	// 直接使用client时响应原样返回，由调用方通过DecodeResponse还原
+	resp, err = srv.Client().Get(srv.URL + "/limited")
+	if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("resp = %v, err = %v", resp, err)
+	}
+	defer resp.Body.Close()
+	if decoded := DecodeResponse(resp); decoded == nil || decoded.Metadata()["remote_code"] != ErrRateLimit.Code {
+		t.Errorf("decoded = %v", decoded)
+	}
+}
@@ -0,0 +1,36 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import "time"
+
+// Clock 时钟抽象，测试中可以注入假时钟避免真实的等待
+type Clock interface {
+	// Now 返回当前时间
+	Now() time.Time
+	// After 在d之后向返回的通道发送当前时间
+	After(d time.Duration) <-chan time.Time
+}
+
+// SystemClock 使用系统时间的时钟
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -13,6 +13,35 @@ type ErrCode struct {
 	Message    string
 	HttpStatus int
 	Type       ErrType
+	// 面向用户的安全信息，为空时使用Message
+	PublicMessage string
+	// 翻译目录中查找本地化消息使用的键，为空时使用Code，
+	// 多个错误码共用同一个Code但消息不同时，需要设置不同的MessageKey
+	MessageKey string
+	// 消息模板中允许引用的命名参数，注册时用于校验模板
+	Params []string
+	// 是否可重试，默认按错误类型判断
+	Retryable Retryability
+	// 严重程度，默认按错误类型推导
+	Severity Severity
+}
+
+// messageKey 返回翻译目录中查找本地化消息使用的键
+func (c *ErrCode) messageKey() string {
+	if c.MessageKey != "" {
+		return c.MessageKey
+	}
+
+	return c.Code
+}
+
+// publicMessage 返回错误码面向用户的安全信息
+func (c *ErrCode) publicMessage() string {
+	if c.PublicMessage != "" {
+		return c.PublicMessage
+	}
+
+	return c.Message
 }
 
 const (
@@ -24,12 +53,20 @@ const (
 	ErrForbiddenMessage       = "Forbidden"
 	ErrConflictMessage        = "Resource Conflict"
 	ErrRateLimitMessage       = "Rate Limit Exceeded"
+	ErrExternalMessage        = "Upstream Service Error"
+	ErrValidationMessage      = "Validation Failed"
 	ErrUsernameExistedMessage = "Username already exists"
 	ErrEmailExistedMessage    = "Email already exists"
 	ErrPhoneExistedMessage    = "Phone already exists"
 	ErrBusinessMessage        = "Business error"
+	ErrPanicRecoveredMessage  = "Service encountered a panic and recovered"
+	ErrCircuitOpenMessage     = "Service temporarily unavailable"
+	ErrCanceledMessage        = "Request Canceled"
 )
 
+// StatusClientClosedRequest 客户端在响应前取消请求时使用的非标准状态码，与nginx的499一致
+const StatusClientClosedRequest = 499
+
 var (
 	ErrInternal = &ErrCode{
 		Code:       ErrTypeInternal.String(),
@@ -79,6 +116,18 @@ var (
 		HttpStatus: http.StatusTooManyRequests,
 		Type:       ErrTypeRateLimit,
 	}
+	ErrValidation = &ErrCode{
+		Code:       ErrTypeValidation.String(),
+		Message:    ErrValidationMessage,
+		HttpStatus: http.StatusBadRequest,
+		Type:       ErrTypeValidation,
+	}
+	ErrExternal = &ErrCode{
+		Code:       ErrTypeExternal.String(),
+		Message:    ErrExternalMessage,
+		HttpStatus: http.StatusBadGateway,
+		Type:       ErrTypeExternal,
+	}
 )
 
 var (
@@ -87,18 +136,21 @@ var (
 		Message:    ErrUsernameExistedMessage,
 		HttpStatus: http.StatusConflict,
 		Type:       ErrTypeConflict,
+		MessageKey: "USERNAME_EXISTED",
 	}
 	ErrEmailExisted = &ErrCode{
 		Code:       ErrTypeConflict.String(),
 		Message:    ErrEmailExistedMessage,
 		HttpStatus: http.StatusConflict,
 		Type:       ErrTypeConflict,
+		MessageKey: "EMAIL_EXISTED",
 	}
 	ErrPhoneExisted = &ErrCode{
 		Code:       ErrTypeConflict.String(),
 		Message:    ErrPhoneExistedMessage,
 		HttpStatus: http.StatusConflict,
 		Type:       ErrTypeConflict,
+		MessageKey: "PHONE_EXISTED",
 	}
 	BusinessError = &ErrCode{
 		Code:       ErrTypeBusiness.String(),
@@ -106,6 +158,26 @@ var (
 		HttpStatus: http.StatusInternalServerError,
 		Type:       ErrTypeBusiness,
 	}
+	ErrPanicRecovered = &ErrCode{
+		Code:       "PANIC_RECOVERED",
+		Message:    ErrPanicRecoveredMessage,
+		HttpStatus: http.StatusInternalServerError,
+		Type:       ErrTypeInternal,
+	}
+	ErrCircuitOpen = &ErrCode{
+		Code:       "CIRCUIT_OPEN",
+		Message:    ErrCircuitOpenMessage,
+		HttpStatus: http.StatusServiceUnavailable,
+		Type:       ErrTypeExternal,
+	}
+	// ErrCanceled 调用方主动取消，属于客户端行为，不可重试，也不计入5xx统计
+	ErrCanceled = &ErrCode{
+		Code:       "CANCELED",
+		Message:    ErrCanceledMessage,
+		HttpStatus: StatusClientClosedRequest,
+		Type:       ErrTypeBadRequest,
+		Retryable:  RetryDisabled,
+	}
 )
 
 // n 创建一个新的错误实例，根据enableStack参数控制是否收集堆栈信息
@@ -116,13 +188,17 @@ var (
 //
 // 返回值:
 //
-//	Error: 新创建的错误实例
-func n(enableStack bool, code *ErrCode) Error {
+//	*ErrorImpl: 新创建的错误实例，不触发创建钩子，由调用方在构造完成后触发
+func n(enableStack bool, code *ErrCode) *ErrorImpl {
 	impl := acquireError()
 	impl.code = code.Code
 	impl.message = code.Message
+	impl.publicMessage = code.publicMessage()
+	impl.messageKey = code.messageKey()
 	impl.httpStatus = code.HttpStatus
 	impl.errType = code.Type
+	impl.retryable = code.Retryable
+	impl.severity = code.Severity
 	impl.timestamp = time.Now().UTC()
 
 	if enableStack {
@@ -141,7 +217,9 @@ func n(enableStack bool, code *ErrCode) Error {
 //
 //	Error: 包含堆栈信息的新错误实例
 func New(code *ErrCode) Error {
-	return n(true, code)
+	impl := n(true, code)
+	fireCreate(impl)
+	return impl
 }
 
 // FastNew 创建一个不带堆栈信息的错误实例，适用于性能敏感场景
@@ -153,15 +231,22 @@ func New(code *ErrCode) Error {
 //
 //	Error: 不包含堆栈信息的新错误实例
 func FastNew(code *ErrCode) Error {
-	return n(false, code)
+	impl := n(false, code)
+	fireCreate(impl)
+	return impl
 }
 
-func nf(enableStack bool, code *ErrCode, format string, args ...any) Error {
+// nf 创建格式化信息的错误实例，不触发创建钩子
+func nf(enableStack bool, code *ErrCode, format string, args ...any) *ErrorImpl {
 	impl := acquireError()
 	impl.code = code.Code
 	impl.message = fmt.Sprintf(format, args...)
+	impl.publicMessage = code.publicMessage()
+	impl.messageKey = code.messageKey()
 	impl.httpStatus = code.HttpStatus
 	impl.errType = code.Type
+	impl.retryable = code.Retryable
+	impl.severity = code.Severity
 	impl.timestamp = time.Now().UTC()
 
 	if enableStack {
@@ -173,41 +258,54 @@ func nf(enableStack bool, code *ErrCode, format string, args ...any) Error {
 
 // Newf 创建一个错误，带堆栈信息格式化的错误
 func Newf(code *ErrCode, format string, args ...any) Error {
-	return nf(true, code, format, args...)
+	impl := nf(true, code, format, args...)
+	fireCreate(impl)
+	return impl
 }
 
 func FastNewf(code *ErrCode, format string, args ...any) Error {
-	return nf(false, code, format, args...)
+	impl := nf(false, code, format, args...)
+	fireCreate(impl)
+	return impl
 }
 
-// wrap 将一个标准error包装成自定义的Error类型，可选择是否包含堆栈信息
-// 参数:
-//
-//	enableStack: 是否启用堆栈跟踪信息收集
-//	err:         需要被包装的原始错误，如果为nil则返回nil
-//	code:        错误码信息，包含错误代码、消息、HTTP状态码和错误类型
-//
-// 返回值:
-//
-//	Error: 包装后的自定义错误类型，如果原始错误已经是自定义类型则直接返回
-func wrap(enableStack bool, err error, code *ErrCode) Error {
+// existingError 判断err是否不需要包装，err为nil时返回nil，err中已经包含Error时返回该Error
+func existingError(err error) (Error, bool) {
 	if err == nil {
-		return nil
+		return nil, true
 	}
 
-	// 检查错误是否已经是自定义的Error类型，如果是则直接返回
 	var customErr Error
 	if errors.As(err, &customErr) {
-		return customErr
+		return customErr, true
 	}
 
+	return nil, false
+}
+
+// wrap 将一个标准error包装成自定义的Error类型，可选择是否包含堆栈信息，
+// 调用方需要先通过existingError排除nil和已经是Error的情况，不触发钩子
+// 参数:
+//
+//	enableStack: 是否启用堆栈跟踪信息收集
+//	err:         需要被包装的原始错误
+//	code:        错误码信息，包含错误代码、消息、HTTP状态码和错误类型
+//
+// 返回值:
+//
+//	*ErrorImpl: 包装后的自定义错误类型
+func wrap(enableStack bool, err error, code *ErrCode) *ErrorImpl {
 	impl := &ErrorImpl{
-		code:       code.Code,
-		message:    code.Message,
-		httpStatus: code.HttpStatus,
-		errType:    code.Type,
-		timestamp:  time.Now().UTC(),
-		cause:      err,
+		code:          code.Code,
+		message:       code.Message,
+		publicMessage: code.publicMessage(),
+		messageKey:    code.messageKey(),
+		httpStatus:    code.HttpStatus,
+		errType:       code.Type,
+		retryable:     code.Retryable,
+		severity:      code.Severity,
+		timestamp:     time.Now().UTC(),
+		cause:         err,
 	}
 
 	// 根据enableStack参数决定是否记录简化版的调用堆栈
@@ -230,7 +328,13 @@ func wrap(enableStack bool, err error, code *ErrCode) Error {
 //
 // 该函数会携带调用堆栈信息
 func Wrap(err error, code *ErrCode) Error {
-	return wrap(true, err, code)
+	if existing, ok := existingError(err); ok {
+		return existing
+	}
+
+	impl := wrap(true, err, code)
+	fireWrap(err, impl)
+	return impl
 }
 
 // FastWrap 将一个标准error包装成自定义的Error类型
@@ -245,7 +349,13 @@ func Wrap(err error, code *ErrCode) Error {
 //
 // 该函数不会携带调用堆栈信息，适用于性能敏感场景
 func FastWrap(err error, code *ErrCode) Error {
-	return wrap(false, err, code)
+	if existing, ok := existingError(err); ok {
+		return existing
+	}
+
+	impl := wrap(false, err, code)
+	fireWrap(err, impl)
+	return impl
 }
 
 // wrapf 将给定的错误包装成自定义错误类型，可选择是否包含堆栈信息
@@ -253,27 +363,20 @@ func FastWrap(err error, code *ErrCode) Error {
 // err: 原始错误，如果为nil则返回nil
 // format: 格式化字符串，用于格式化错误码中的消息
 // code: 错误码信息，包含错误代码、HTTP状态码、错误类型等信息
-// 返回值: 包装后的自定义错误类型，如果输入错误为nil则返回nil
-func wrapf(enableStack bool, err error, format string, code *ErrCode) Error {
-	// 如果原始错误为nil，直接返回nil
-	if err == nil {
-		return nil
-	}
-
-	// 检查原始错误是否已经是自定义错误类型，如果是则直接返回
-	var customErr Error
-	if errors.As(err, &customErr) {
-		return customErr
-	}
-
+// 返回值: 包装后的自定义错误类型，调用方需要先通过existingError排除nil和已经是Error的情况，不触发钩子
+func wrapf(enableStack bool, err error, format string, code *ErrCode) *ErrorImpl {
 	// 创建新的错误实现，包装原始错误并添加错误码信息
 	impl := &ErrorImpl{
-		code:       code.Code,
-		message:    fmt.Sprintf(format, code.Message),
-		httpStatus: code.HttpStatus,
-		errType:    code.Type,
-		timestamp:  time.Now().UTC(),
-		cause:      err,
+		code:          code.Code,
+		message:       fmt.Sprintf(format, code.Message),
+		publicMessage: code.publicMessage(),
+		messageKey:    code.messageKey(),
+		httpStatus:    code.HttpStatus,
+		errType:       code.Type,
+		retryable:     code.Retryable,
+		severity:      code.Severity,
+		timestamp:     time.Now().UTC(),
+		cause:         err,
 	}
 
 	if enableStack {
@@ -290,7 +393,13 @@ func wrapf(enableStack bool, err error, format string, code *ErrCode) Error {
 // code: 错误码信息，包含错误代码、HTTP状态码、错误类型等信息
 // 返回值: 包装后的自定义错误类型，如果输入错误为nil则返回nil
 func Wrapf(format string, err error, code *ErrCode) Error {
-	return wrapf(true, err, format, code)
+	if existing, ok := existingError(err); ok {
+		return existing
+	}
+
+	impl := wrapf(true, err, format, code)
+	fireWrap(err, impl)
+	return impl
 }
 
 // FastWrapf 将给定的错误包装成自定义错误类型，不包含堆栈信息，性能更高
@@ -299,7 +408,13 @@ func Wrapf(format string, err error, code *ErrCode) Error {
 // code: 错误码信息，包含错误代码、HTTP状态码、错误类型等信息
 // 返回值: 包装后的自定义错误类型，如果输入错误为nil则返回nil
 func FastWrapf(format string, err error, code *ErrCode) Error {
-	return wrapf(false, err, format, code)
+	if existing, ok := existingError(err); ok {
+		return existing
+	}
+
+	impl := wrapf(false, err, format, code)
+	fireWrap(err, impl)
+	return impl
 }
 
 // ==================== 基础错误函数，带堆栈信息 ====================
@@ -10,6 +10,8 @@ type Error interface {
 	error
 	Code() string
 	Message() string
+	PublicMessage() string
+	Detail() string
 	HttpStatus() int
 	Type() ErrType
 	Timestamp() time.Time
@@ -45,10 +47,20 @@ type ErrorImpl struct {
 	code string
 	// 详细信息
 	message string
+	// 面向用户的安全信息，可以直接返回给客户端
+	publicMessage string
+	// 翻译目录中查找本地化消息使用的键
+	messageKey string
+	// 面向开发者的内部详情，只用于日志记录
+	detail string
 	// http状态码
 	httpStatus int
 	// 错误类型
 	errType ErrType
+	// 是否可重试
+	retryable Retryability
+	// 严重程度
+	severity Severity
 	// 时间戳
 	timestamp time.Time
 	// 堆栈信息
@@ -75,6 +87,33 @@ func (e *ErrorImpl) Message() string {
 	return e.message
 }
 
+// PublicMessage 返回面向用户的安全信息，未设置时返回Message
+func (e *ErrorImpl) PublicMessage() string {
+	if e.publicMessage != "" {
+		return e.publicMessage
+	}
+
+	return e.message
+}
+
+// MessageKey 返回翻译目录中查找本地化消息使用的键，未设置时返回Code
+func (e *ErrorImpl) MessageKey() string {
+	if e.messageKey != "" {
+		return e.messageKey
+	}
+
+	return e.code
+}
+
+// Detail 返回面向开发者的内部详情，未设置时返回Message
+func (e *ErrorImpl) Detail() string {
+	if e.detail != "" {
+		return e.detail
+	}
+
+	return e.message
+}
+
 func (e *ErrorImpl) HttpStatus() int {
 	return e.httpStatus
 }
@@ -83,6 +122,20 @@ func (e *ErrorImpl) Type() ErrType {
 	return e.errType
 }
 
+// Retryability 返回错误码或构造器指定的重试标记
+func (e *ErrorImpl) Retryability() Retryability {
+	return e.retryable
+}
+
+// Severity 返回错误码或构造器指定的严重程度，未指定时按错误类型推导
+func (e *ErrorImpl) Severity() Severity {
+	if e.severity != SeverityByType {
+		return e.severity
+	}
+
+	return TypeSeverity(e.errType)
+}
+
 func (e *ErrorImpl) Timestamp() time.Time {
 	return e.timestamp
 }
@@ -0,0 +1,432 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// BSD sysexits.h风格的进程退出码
+const (
+	ExitOK          = 0
+	ExitFailure     = 1
+	ExitUsage       = 64
+	ExitDataErr     = 65
+	ExitNoInput     = 66
+	ExitUnavailable = 69
+	ExitSoftware    = 70
+	ExitTempFail    = 75
+	ExitNoPerm      = 77
+)
+
+// ExitCanceled 操作被取消时的退出码，与shell中Ctrl-C中断（128+SIGINT）的退出码一致
+const ExitCanceled = 130
+
+// ExitCodes 错误到进程退出码的映射，优先按错误码查找，其次按错误类型，都没有时使用兜底退出码，并发安全。
+// 只作用于fn返回的错误，Main和MainContext因信号结束时按shell的约定以128+信号值退出（如SIGINT为130、
+// SIGTERM为143），不经过退出码表
+type ExitCodes struct {
+	mu sync.RWMutex
+	// 错误码 -> 退出码
+	byCode map[string]int
+	// 错误类型 -> 退出码
+	byType map[ErrType]int
+	// 兜底的退出码
+	fallback int
+}
+
+// NewExitCodes 创建带有默认映射的退出码表，CANCELED按错误码映射，优先于其BAD_REQUEST类型
+//   - CANCELED -> 130 (与SIGINT中断一致)
+//   - BAD_REQUEST -> 64 (EX_USAGE)
+//   - VALIDATION、CONFLICT -> 65 (EX_DATAERR)
+//   - NOT_FOUND -> 66 (EX_NOINPUT)
+//   - EXTERNAL -> 69 (EX_UNAVAILABLE)
+//   - INTERNAL -> 70 (EX_SOFTWARE)
+//   - TIMEOUT、RATE_LIMIT -> 75 (EX_TEMPFAIL)
+//   - UNAUTHORIZED、FORBIDDEN -> 77 (EX_NOPERM)
+//   - 其它 -> 1
+func NewExitCodes() *ExitCodes {
+	return &ExitCodes{
+		byCode: map[string]int{
+			ErrCanceled.Code: ExitCanceled,
+		},
+		byType: map[ErrType]int{
+			ErrTypeBadRequest:   ExitUsage,
+			ErrTypeValidation:   ExitDataErr,
+			ErrTypeConflict:     ExitDataErr,
+			ErrTypeNotFound:     ExitNoInput,
+			ErrTypeExternal:     ExitUnavailable,
+			ErrTypeInternal:     ExitSoftware,
+			ErrTypeTimeout:      ExitTempFail,
+			ErrTypeRateLimit:    ExitTempFail,
+			ErrTypeUnauthorized: ExitNoPerm,
+			ErrTypeForbidden:    ExitNoPerm,
+		},
+		fallback: ExitFailure,
+	}
+}
+
+// DefaultExitCodes 默认的退出码表，Main和MainContext默认使用
+var DefaultExitCodes = NewExitCodes()
+
+// SetCode 设置错误码的退出码，优先于错误类型的映射
+func (c *ExitCodes) SetCode(code *ErrCode, exit int) *ExitCodes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.byCode[code.Code] = exit
+	return c
+}
+
+// SetType 设置错误类型的退出码
+func (c *ExitCodes) SetType(t ErrType, exit int) *ExitCodes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.byType[t] = exit
+	return c
+}
+
+// SetFallback 设置兜底的退出码，默认为1
+func (c *ExitCodes) SetFallback(exit int) *ExitCodes {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fallback = exit
+	return c
+}
+
+// ExitCode 返回错误的退出码，nil为0，非Error类型的错误先经过DefaultClassifier分类，
+// 没有匹配的分类规则时使用兜底退出码
+func (c *ExitCodes) ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var code string
+	var errType ErrType
+	var e Error
+	if errors.As(err, &e) {
+		code, errType = e.Code(), e.Type()
+	} else if rule, ok := DefaultClassifier.Match(err); ok {
+		code, errType = rule.Code.Code, rule.Code.Type
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if exit, ok := c.byCode[code]; ok {
+		return exit
+	}
+	if exit, ok := c.byType[errType]; ok {
+		return exit
+	}
+
+	return c.fallback
+}
+
+// ExitCode 使用DefaultExitCodes返回错误的退出码
+func ExitCode(err error) int {
+	return DefaultExitCodes.ExitCode(err)
+}
+
+// MetadataSignal 因信号结束时记录信号名称的元数据键
+const MetadataSignal = "signal"
+
+// runConfig 命令行运行的配置
+type runConfig struct {
+	// 命令行参数，用于检测--verbose
+	args []string
+	// 错误输出
+	stderr io.Writer
+	// 退出进程的函数
+	exit func(code int)
+	// 是否输出详情和堆栈，nil时根据命令行参数检测
+	verbose *bool
+	// 监听的信号
+	signals []os.Signal
+	// 收到信号后等待fn返回的时间
+	grace time.Duration
+	// 退出码表
+	codes *ExitCodes
+	// 脱敏器，作用于输出的元数据
+	redactor *Redactor
+	// 时钟，用于宽限期的等待
+	clock Clock
+}
+
+type RunOption func(c *runConfig)
+
+// WithRunArgs 设置用于检测--verbose的命令行参数，默认为os.Args[1:]
+func WithRunArgs(args []string) RunOption {
+	return func(c *runConfig) {
+		c.args = args
+	}
+}
+
+// WithRunStderr 设置错误输出，默认为os.Stderr
+func WithRunStderr(w io.Writer) RunOption {
+	return func(c *runConfig) {
+		c.stderr = w
+	}
+}
+
+// WithRunExit 设置退出进程的函数，默认为os.Exit
+func WithRunExit(exit func(code int)) RunOption {
+	return func(c *runConfig) {
+		c.exit = exit
+	}
+}
+
+// WithRunVerbose 设置是否输出详情、元数据和堆栈，默认在命令行参数包含--verbose或-verbose时输出
+func WithRunVerbose(verbose bool) RunOption {
+	return func(c *runConfig) {
+		c.verbose = &verbose
+	}
+}
+
+// WithRunSignals 设置监听的信号，默认为SIGINT和SIGTERM，不传入信号时不监听
+func WithRunSignals(signals ...os.Signal) RunOption {
+	return func(c *runConfig) {
+		c.signals = signals
+	}
+}
+
+// WithRunGracePeriod 设置MainContext收到信号取消上下文后等待fn返回的时间，默认10秒
+func WithRunGracePeriod(d time.Duration) RunOption {
+	return func(c *runConfig) {
+		c.grace = d
+	}
+}
+
+// WithExitCodes 设置退出码表，默认为DefaultExitCodes
+func WithExitCodes(codes *ExitCodes) RunOption {
+	return func(c *runConfig) {
+		c.codes = codes
+	}
+}
+
+// WithRunRedactor 设置输出元数据时使用的脱敏器，默认为DefaultRedactor，传入nil时关闭脱敏
+func WithRunRedactor(r *Redactor) RunOption {
+	return func(c *runConfig) {
+		c.redactor = r
+	}
+}
+
+// newRunConfig 根据选项创建配置
+func newRunConfig(opts []RunOption) *runConfig {
+	c := &runConfig{
+		stderr:   os.Stderr,
+		exit:     os.Exit,
+		signals:  []os.Signal{os.Interrupt, syscall.SIGTERM},
+		grace:    10 * time.Second,
+		codes:    DefaultExitCodes,
+		redactor: DefaultRedactor(),
+		clock:    SystemClock{},
+	}
+	if len(os.Args) > 1 {
+		c.args = os.Args[1:]
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.verbose == nil {
+		verbose := slices.Contains(c.args, "--verbose") || slices.Contains(c.args, "-verbose")
+		c.verbose = &verbose
+	}
+
+	return c
+}
+
+// mainGracePeriod Main收到信号后默认等待fn返回的时间
+const mainGracePeriod = time.Second
+
+// Main 执行命令行程序的主函数，fn返回的错误输出到stderr并按退出码表退出进程，
+// fn的panic会被恢复为PanicError。
+//
+// 注意：fn无法感知信号，收到信号后最多等待1秒（可通过WithRunGracePeriod修改），fn仍未返回时
+// 直接以128+信号值退出进程，fn中的defer不会执行。需要在退出前清理资源（刷新缓冲、删除临时文件、
+// 释放锁等）时使用MainContext并在ctx取消后返回
+func Main(fn func() error, opts ...RunOption) {
+	MainContext(func(context.Context) error {
+		return fn()
+	}, mainOptions(opts)...)
+}
+
+// mainOptions 在调用方的选项之前加上Main的默认选项
+func mainOptions(opts []RunOption) []RunOption {
+	return append([]RunOption{WithRunGracePeriod(mainGracePeriod)}, opts...)
+}
+
+// MainContext 与Main相同，但fn可以接收上下文，收到信号时取消上下文并在宽限期内等待fn返回，
+// 宽限期内再次收到信号或超过宽限期时立即退出，因信号结束时总是以128+信号值退出
+func MainContext(fn func(ctx context.Context) error, opts ...RunOption) {
+	c := newRunConfig(opts)
+
+	sigCh := make(chan os.Signal, 2)
+	if len(c.signals) > 0 {
+		signal.Notify(sigCh, c.signals...)
+		defer signal.Stop(sigCh)
+	}
+
+	c.exit(run(context.Background(), fn, c, sigCh))
+}
+
+// run 执行fn并返回退出码，sigCh收到信号时取消fn的上下文
+func run(parent context.Context, fn func(ctx context.Context) error, c *runConfig, sigCh <-chan os.Signal) int {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		var err error
+		defer func() {
+			done <- err
+		}()
+		defer Recover(&err)
+
+		err = fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case sig := <-sigCh:
+		cancel()
+		err = c.waitAfterSignal(sig, done, sigCh)
+		RenderError(c.stderr, err, *c.verbose, c.redactor)
+		return signalExitCode(sig)
+	}
+
+	if err == nil {
+		return ExitOK
+	}
+
+	RenderError(c.stderr, err, *c.verbose, c.redactor)
+	return c.codes.ExitCode(err)
+}
+
+// waitAfterSignal 收到信号后在宽限期内等待fn返回，返回描述信号的CANCELED错误，fn返回的错误作为原始错误
+func (c *runConfig) waitAfterSignal(sig os.Signal, done <-chan error, sigCh <-chan os.Signal) Error {
+	var cause error
+	if c.grace > 0 {
+		select {
+		case cause = <-done:
+		case <-sigCh:
+			cause = fmt.Errorf("received second signal")
+		case <-c.clock.After(c.grace):
+			cause = fmt.Errorf("did not stop within %s", c.grace)
+		}
+	}
+
+	// fn因上下文取消而返回的错误没有额外的信息，不作为原始错误
+	if errors.Is(cause, context.Canceled) {
+		cause = nil
+	}
+
+	return NewBuilder().
+		WithCode(ErrCanceled).
+		WithMessage("canceled by signal "+sig.String()).
+		WithCause(cause).
+		WithMetadata(MetadataSignal, sig.String()).
+		Build()
+}
+
+// signalExitCode 返回因信号结束时的退出码，按shell的约定为128+信号值
+func signalExitCode(sig os.Signal) int {
+	if s, ok := sig.(syscall.Signal); ok {
+		return 128 + int(s)
+	}
+
+	return ExitTempFail
+}
+
+// RenderError 将错误以便于阅读的格式输出到w，第一行为"程序名: 错误信息 (错误码)"，
+// 之后依次输出原始错误链，verbose为true时额外输出脱敏后的详情和元数据以及堆栈
+func RenderError(w io.Writer, err error, verbose bool, redactor *Redactor) {
+	if err == nil {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(filepath.Base(os.Args[0]))
+	sb.WriteString(": ")
+
+	var e Error
+	if !errors.As(err, &e) {
+		sb.WriteString(err.Error())
+		sb.WriteByte('\n')
+		_, _ = io.WriteString(w, sb.String())
+		return
+	}
+
+	// 标准错误包装的Error，信息中已经包含了整个错误链
+	if _, ok := err.(Error); !ok {
+		fmt.Fprintf(&sb, "%s (%s)\n", err.Error(), e.Code())
+	} else {
+		fmt.Fprintf(&sb, "%s (%s)\n", e.Message(), e.Code())
+		if multi, ok := e.(*MultiError); ok {
+			for _, child := range multi.Errors() {
+				fmt.Fprintf(&sb, "  - %s (%s)\n", child.Message(), child.Code())
+			}
+		}
+		renderCauses(&sb, e.Unwrap())
+	}
+
+	if verbose {
+		if detail := redactor.RedactString(e.Detail()); detail != "" {
+			fmt.Fprintf(&sb, "  detail: %s\n", detail)
+		}
+		if metadata := redactor.RedactMetadata(e.Metadata()); len(metadata) > 0 {
+			sb.WriteString("  metadata:\n")
+			for _, k := range slices.Sorted(maps.Keys(metadata)) {
+				fmt.Fprintf(&sb, "    %s: %v\n", k, metadata[k])
+			}
+		}
+		if stack := e.StackTrace(); stack != "" {
+			sb.WriteString(stack)
+			if !strings.HasSuffix(stack, "\n") {
+				sb.WriteByte('\n')
+			}
+		}
+	}
+
+	_, _ = io.WriteString(w, sb.String())
+}
+
+// renderCauses 逐行输出原始错误链，遇到非Error类型的错误时输出其完整信息后结束
+func renderCauses(sb *strings.Builder, cause error) {
+	for ; cause != nil; cause = errors.Unwrap(cause) {
+		ce, ok := cause.(Error)
+		if !ok {
+			fmt.Fprintf(sb, "  caused by: %s\n", cause.Error())
+			return
+		}
+		fmt.Fprintf(sb, "  caused by: %s (%s)\n", ce.Message(), ce.Code())
+	}
+}
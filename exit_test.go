@@ -0,0 +1,199 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestExitCode 测试默认的退出码映射以及按错误码和错误类型覆盖
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want int
+	}{
+		{name: "nil", err: nil, want: ExitOK},
+		{name: "bad request", err: New(ErrBadRequest), want: ExitUsage},
+		{name: "not found", err: New(ErrNotFound), want: ExitNoInput},
+		{name: "unauthorized", err: New(ErrUnauthorized), want: ExitNoPerm},
+		{name: "timeout", err: fmt.Errorf("fetch: %w", New(ErrTimeout)), want: ExitTempFail},
+		{name: "internal", err: New(ErrInternal), want: ExitSoftware},
+		{name: "panic", err: NewPanicError("boom", nil), want: ExitSoftware},
+		{name: "stdlib classified", err: os.ErrNotExist, want: ExitNoInput},
+		{name: "canceled", err: New(ErrCanceled), want: ExitCanceled},
+		{name: "context canceled", err: fmt.Errorf("sync: %w", context.Canceled), want: ExitCanceled},
+		{name: "stdlib unknown", err: errors.New("x"), want: ExitFailure},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ExitCode(tt.err); got != tt.want {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	codes := NewExitCodes().SetCode(errPaymentLost, 3).SetType(ErrTypeNotFound, 4).SetFallback(2)
+	if got := codes.ExitCode(New(errPaymentLost)); got != 3 {
+		t.Errorf("按错误码覆盖 = %d", got)
+	}
+	if got := codes.ExitCode(New(ErrNotFound)); got != 4 {
+		t.Errorf("按错误类型覆盖 = %d", got)
+	}
+	if got := codes.ExitCode(errors.New("x")); got != 2 {
+		t.Errorf("兜底退出码 = %d", got)
+	}
+}
+
+// TestRenderError 测试错误输出的格式和verbose
+func TestRenderError(t *testing.T) {
+	err := NewBuilder().WithCode(ErrExternal).WithCause(errors.New("connection refused")).
+		WithDetail("dial as alice@example.com").Build().
+		WithMetadata("host", "db.internal").
+		WithMetadata("password", "hunter2")
+
+	var buf bytes.Buffer
+	RenderError(&buf, err, false, DefaultRedactor())
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], ": "+err.Message()+" (EXTERNAL)") || lines[1] != "  caused by: connection refused" {
+		t.Errorf("输出:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	RenderError(&buf, err, true, DefaultRedactor())
+	out := buf.String()
+	if !strings.Contains(out, "    host: db.internal") || strings.Contains(out, "hunter2") || strings.Contains(out, "alice@example.com") ||
+		!strings.Contains(out, "  detail: ") || !strings.Contains(out, "exit_test.go") {
+		t.Errorf("verbose输出:\n%s", out)
+	}
+
+	buf.Reset()
+	RenderError(&buf, fmt.Errorf("sync: %w", New(ErrNotFound)), false, nil)
+	if !strings.HasSuffix(strings.TrimSpace(buf.String()), "sync: "+ErrNotFound.Message+" (NOT_FOUND)") {
+		t.Errorf("包装的Error输出: %s", buf.String())
+	}
+}
+
+// TestRun 测试运行结果、panic恢复和信号处理
+func TestRun(t *testing.T) {
+	newConfig := func(buf *bytes.Buffer, opts ...RunOption) *runConfig {
+		c := newRunConfig(append([]RunOption{WithRunStderr(buf), WithRunArgs(nil), WithRunSignals()}, opts...))
+		c.clock = newFakeClock()
+		return c
+	}
+
+	t.Run("success", func(t *testing.T) {
+		var buf bytes.Buffer
+		code := run(context.Background(), func(context.Context) error { return nil }, newConfig(&buf), nil)
+		if code != ExitOK || buf.Len() != 0 {
+			t.Errorf("code = %d, output = %q", code, buf.String())
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		var buf bytes.Buffer
+		code := run(context.Background(), func(context.Context) error {
+			return New(ErrNotFound)
+		}, newConfig(&buf, WithRunArgs([]string{"sync", "--verbose"})), nil)
+		if code != ExitNoInput || !strings.Contains(buf.String(), "(NOT_FOUND)") || !strings.Contains(buf.String(), "Stack") {
+			t.Errorf("code = %d, output:\n%s", code, buf.String())
+		}
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		var buf bytes.Buffer
+		code := run(context.Background(), func(context.Context) error {
+			panic("boom")
+		}, newConfig(&buf), nil)
+		if code != ExitSoftware || !strings.Contains(buf.String(), "boom") {
+			t.Errorf("code = %d, output = %q", code, buf.String())
+		}
+	})
+
+	t.Run("signal graceful", func(t *testing.T) {
+		var buf bytes.Buffer
+		sigCh := make(chan os.Signal, 1)
+		sigCh <- syscall.SIGTERM
+		code := run(context.Background(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, newRunConfig([]RunOption{WithRunStderr(&buf), WithRunGracePeriod(time.Hour)}), sigCh)
+		if code != 128+int(syscall.SIGTERM) || !strings.Contains(buf.String(), "canceled by signal terminated (CANCELED)") {
+			t.Errorf("code = %d, output = %q", code, buf.String())
+		}
+	})
+
+	t.Run("signal grace exceeded", func(t *testing.T) {
+		var buf bytes.Buffer
+		block := make(chan struct{})
+		defer close(block)
+
+		sigCh := make(chan os.Signal, 1)
+		sigCh <- os.Interrupt
+		code := run(context.Background(), func(context.Context) error {
+			<-block
+			return nil
+		}, newConfig(&buf, WithRunGracePeriod(time.Second)), sigCh)
+		if code != 128+int(syscall.SIGINT) || !strings.Contains(buf.String(), "did not stop within 1s") {
+			t.Errorf("code = %d, output = %q", code, buf.String())
+		}
+	})
+}
+
+// TestMain_Exit 测试Main使用退出函数退出
+func TestMain_Exit(t *testing.T) {
+	var buf bytes.Buffer
+	code := -1
+	Main(func() error {
+		return New(ErrUnauthorized)
+	}, WithRunStderr(&buf), WithRunExit(func(c int) { code = c }), WithRunSignals())
+
+	if code != ExitNoPerm || !strings.Contains(buf.String(), "(UNAUTHORIZED)") {
+		t.Errorf("code = %d, output = %q", code, buf.String())
+	}
+}
+
+// TestMain_GracePeriod 测试Main收到信号后在默认宽限期内等待fn返回，fn的defer可以执行
+func TestMain_GracePeriod(t *testing.T) {
+	var buf bytes.Buffer
+	release := make(chan struct{})
+	cleaned := false
+
+	sigCh := make(chan os.Signal, 1)
+	sigCh <- syscall.SIGTERM
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	c := newRunConfig(mainOptions([]RunOption{WithRunStderr(&buf), WithRunArgs(nil), WithRunSignals()}))
+	code := run(context.Background(), func(context.Context) error {
+		defer func() { cleaned = true }()
+		<-release
+		return nil
+	}, c, sigCh)
+
+	if c.grace != mainGracePeriod || !cleaned {
+		t.Errorf("grace = %s, cleaned = %v", c.grace, cleaned)
+	}
+	if code != 128+int(syscall.SIGTERM) {
+		t.Errorf("code = %d, want %d", code, 128+int(syscall.SIGTERM))
+	}
+}
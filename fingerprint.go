@@ -0,0 +1,224 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Fingerprinter 计算错误的指纹，同一个问题产生的错误实例具有相同的指纹
+// 指纹由错误码、错误类型、归一化后的栈顶若干帧和原始错误链的类型组成，
+// 默认忽略消息和行号，避免消息参数和代码行号变化导致指纹变化
+type Fingerprinter struct {
+	// 参与计算的栈顶帧数
+	frames int
+	// 是否包含行号
+	lines bool
+	// 是否包含归一化后的消息
+	message bool
+}
+
+type FingerprintOption func(f *Fingerprinter)
+
+// WithFingerprintFrames 设置参与计算的栈顶帧数，默认3，0表示不使用堆栈
+func WithFingerprintFrames(n int) FingerprintOption {
+	return func(f *Fingerprinter) {
+		f.frames = max(0, n)
+	}
+}
+
+// WithFingerprintLines 设置是否包含行号，默认不包含
+func WithFingerprintLines(lines bool) FingerprintOption {
+	return func(f *Fingerprinter) {
+		f.lines = lines
+	}
+}
+
+// WithFingerprintMessage 设置是否包含消息，消息中的数字、十六进制、UUID和引号内容会被归一化，默认不包含
+func WithFingerprintMessage(message bool) FingerprintOption {
+	return func(f *Fingerprinter) {
+		f.message = message
+	}
+}
+
+// NewFingerprinter 创建指纹计算器
+func NewFingerprinter(opts ...FingerprintOption) *Fingerprinter {
+	f := &Fingerprinter{frames: 3}
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// DefaultFingerprinter 默认的指纹计算器
+var DefaultFingerprinter = NewFingerprinter()
+
+// Fingerprint 使用DefaultFingerprinter计算错误的指纹，err为nil时返回空字符串
+func Fingerprint(err error) string {
+	return DefaultFingerprinter.Fingerprint(err)
+}
+
+// Fingerprint 计算错误的指纹，返回16位十六进制字符串，err为nil时返回空字符串
+func (f *Fingerprinter) Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	parts := f.parts(err)
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// parts 返回参与计算指纹的各个部分
+func (f *Fingerprinter) parts(err error) []string {
+	var e Error
+	if !errors.As(err, &e) {
+		parts := []string{"type:" + fmt.Sprintf("%T", err)}
+		if f.message {
+			parts = append(parts, "message:"+normalizeMessage(err.Error()))
+		}
+		return append(parts, causeTypes(errors.Unwrap(err))...)
+	}
+
+	parts := []string{"code:" + e.Code(), "type:" + e.Type().String()}
+	if f.message {
+		parts = append(parts, "message:"+normalizeMessage(e.Message()))
+	}
+
+	if f.frames > 0 {
+		for _, frame := range parseStackFrames(e.StackTrace(), f.frames) {
+			if f.lines && frame.line != "" {
+				parts = append(parts, "frame:"+frame.function+":"+frame.line)
+			} else {
+				parts = append(parts, "frame:"+frame.function)
+			}
+		}
+	}
+
+	if multi, ok := e.(*MultiError); ok {
+		for _, child := range multi.Errors() {
+			parts = append(parts, "child:"+f.Fingerprint(child))
+		}
+		return parts
+	}
+
+	return append(parts, causeTypes(e.Unwrap())...)
+}
+
+// causeTypes 返回原始错误链中每个错误的类型
+func causeTypes(err error) []string {
+	var types []string
+	walkErrors(err, func(e error) bool {
+		types = append(types, "cause:"+fmt.Sprintf("%T", e))
+		return false
+	})
+
+	return types
+}
+
+var messagePatterns = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'`), "<str>"},
+	{regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`), "<uuid>"},
+	{regexp.MustCompile(`(?i)\b(0x[0-9a-f]+|[0-9a-f]{8,})\b`), "<hex>"},
+	{regexp.MustCompile(`\d+(\.\d+)?`), "<num>"},
+}
+
+// normalizeMessage 将消息中的变量部分替换为占位符
+func normalizeMessage(message string) string {
+	for _, p := range messagePatterns {
+		message = p.pattern.ReplaceAllString(message, p.replacement)
+	}
+
+	return message
+}
+
+// stackFrame 归一化后的堆栈帧
+type stackFrame struct {
+	function string
+	file     string
+	line     string
+}
+
+// parseStackFrames 解析堆栈文本中的前n帧，兼容本库的完整堆栈、简化堆栈和debug.Stack()的格式，
+// 跳过runtime的帧
+func parseStackFrames(stack string, n int) []stackFrame {
+	var frames []stackFrame
+	for raw := range strings.SplitSeq(stack, "\n") {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "" || line == "Stack Trace:" || line == "Simplified Stack:" ||
+			strings.HasPrefix(line, "goroutine ") || strings.HasPrefix(line, "created by "):
+			continue
+		case isLocationLine(raw, line):
+			// 完整堆栈和debug.Stack()中函数名的下一行是文件位置
+			if len(frames) > 0 && frames[len(frames)-1].line == "" {
+				frames[len(frames)-1].file, frames[len(frames)-1].line = splitLocation(line)
+			}
+			continue
+		}
+
+		frame := stackFrame{function: line}
+		// 简化堆栈的格式为 "function (file:line)"
+		if i := strings.LastIndex(line, " ("); i > 0 && strings.HasSuffix(line, ")") {
+			frame.function = line[:i]
+			frame.file, frame.line = splitLocation(line[i+2 : len(line)-1])
+		} else if i := strings.LastIndex(line, "("); i > 0 && strings.HasSuffix(line, ")") {
+			// debug.Stack()的函数名后带有参数
+			frame.function = line[:i]
+		}
+
+		if strings.HasPrefix(frame.function, "runtime.") {
+			continue
+		}
+
+		if len(frames) == n {
+			break
+		}
+		frames = append(frames, frame)
+	}
+
+	return frames
+}
+
+// isLocationLine 判断是否为缩进的 "file:line" 文件位置行
+func isLocationLine(raw, line string) bool {
+	if !strings.HasPrefix(raw, "\t") && !strings.HasPrefix(raw, "    ") {
+		return false
+	}
+
+	_, number := splitLocation(line)
+	_, err := strconv.Atoi(number)
+	return err == nil
+}
+
+// splitLocation 将 "file.go:123 +0x1a" 拆分为文件和行号
+func splitLocation(location string) (string, string) {
+	location, _, _ = strings.Cut(location, " ")
+	if i := strings.LastIndex(location, ":"); i >= 0 {
+		return location[:i], location[i+1:]
+	}
+
+	return location, ""
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+)
+
+//go:noinline
+func loadUser(id int) Error {
+	return Newf(ErrNotFound, "user %d not found", id)
+}
+
+//go:noinline
+func loadUserTwoSites(id int) Error {
+	if id%2 == 0 {
+		return Newf(ErrNotFound, "user %d not found", id)
+	}
+	return Newf(ErrNotFound, "user %d not found", id)
+}
+
+// TestFingerprint 测试同一问题的错误具有相同指纹
+func TestFingerprint(t *testing.T) {
+	if Fingerprint(loadUser(1)) != Fingerprint(loadUser(2)) {
+		t.Error("消息参数不同的错误应该具有相同的指纹")
+	}
+	if len(Fingerprint(loadUser(1))) != 16 {
+		t.Errorf("指纹长度 = %d, want 16", len(Fingerprint(loadUser(1))))
+	}
+	if Fingerprint(loadUser(1)) == Fingerprint(Newf(ErrNotFound, "user %d not found", 1)) {
+		t.Error("调用位置不同的错误应该具有不同的指纹")
+	}
+	if Fingerprint(FastNew(ErrNotFound)) == Fingerprint(FastNew(ErrTimeout)) {
+		t.Error("错误码不同的错误应该具有不同的指纹")
+	}
+	if Fingerprint(FastWrap(io.EOF, ErrInternal)) == Fingerprint(FastWrap(&os.PathError{Err: io.EOF}, ErrInternal)) {
+		t.Error("原始错误类型不同的错误应该具有不同的指纹")
+	}
+	if Fingerprint(nil) != "" {
+		t.Error("nil的指纹应该为空")
+	}
+
+	// 默认忽略行号
+	if Fingerprint(loadUserTwoSites(1)) != Fingerprint(loadUserTwoSites(2)) {
+		t.Error("默认应该忽略行号")
+	}
+	withLines := NewFingerprinter(WithFingerprintLines(true))
+	if withLines.Fingerprint(loadUserTwoSites(1)) == withLines.Fingerprint(loadUserTwoSites(2)) {
+		t.Error("包含行号时不同行的错误应该具有不同的指纹")
+	}
+
+	withMessage := NewFingerprinter(WithFingerprintMessage(true), WithFingerprintFrames(0))
+	a := withMessage.Fingerprint(FastNewf(ErrNotFound, `order 42 of "alice" (id 5f2c9a7e-0c1d-4f7b-9a3e-1b2c3d4e5f60) missing`))
+	b := withMessage.Fingerprint(FastNewf(ErrNotFound, `order 7 of "bob" (id 0a1b2c3d-4e5f-4a7b-8c9d-0e1f2a3b4c5d) missing`))
+	c := withMessage.Fingerprint(FastNewf(ErrNotFound, "order 7 cancelled"))
+	if a != b || a == c {
+		t.Error("消息中的变量部分应该被归一化")
+	}
+
+	plain := fmt.Errorf("read config: %w", io.EOF)
+	if Fingerprint(plain) != Fingerprint(fmt.Errorf("read other: %w", io.EOF)) {
+		t.Error("非Error类型的错误应该按类型计算指纹")
+	}
+	if Fingerprint(plain) == Fingerprint(errors.New("x")) {
+		t.Error("原始错误链不同时指纹应该不同")
+	}
+}
+
+// TestParseStackFrames 测试解析各种格式的堆栈
+func TestParseStackFrames(t *testing.T) {
+	tests := []struct {
+		name  string
+		stack string
+		want  []stackFrame
+	}{
+		{
+			name:  "simplified",
+			stack: "Simplified Stack:\n  svc.(*User).Load (user.go:42)\n  main.main (main.go:10)\n",
+			want:  []stackFrame{{"svc.(*User).Load", "user.go", "42"}, {"main.main", "main.go", "10"}},
+		},
+		{
+			name:  "full",
+			stack: "Stack Trace:\n  svc.Load\n    /app/svc/user.go:42\n  main.main\n    /app/main.go:10\n",
+			want:  []stackFrame{{"svc.Load", "/app/svc/user.go", "42"}, {"main.main", "/app/main.go", "10"}},
+		},
+		{
+			name: "debug",
+			stack: "goroutine 7 [running]:\nruntime.sigpanic()\n\t/go/src/runtime/signal_unix.go:917 +0x2a\n" +
+				"svc.(*User).Load(0xc000010000, {0x1, 0x2})\n\t/app/svc/user.go:42 +0x1d\n" +
+				"created by main.start in goroutine 1\n\t/app/main.go:20 +0x3c\n",
+			want: []stackFrame{{"svc.(*User).Load", "/app/svc/user.go", "42"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseStackFrames(tt.stack, 5)
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseStackFrames() = %v, want %v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("frame[%d] = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if got := parseStackFrames("Simplified Stack:\n  a (a.go:1)\n  b (b.go:2)\n  c (c.go:3)\n", 2); len(got) != 2 {
+		t.Errorf("应该只返回前2帧, got %v", got)
+	}
+}
@@ -0,0 +1,184 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// GroupMode Group收集错误的模式
+type GroupMode int
+
+const (
+	// GroupCollectAll 收集所有失败，Wait返回AggregateWorst策略的MultiError
+	GroupCollectAll GroupMode = iota
+	// GroupFirstOnly 只保留第一个失败，Wait直接返回该错误
+	GroupFirstOnly
+)
+
+// Group 并发执行一组函数并收集错误，类似errgroup.Group，
+// 区别在于panic会被恢复为PanicError，且可以收集所有失败
+type Group struct {
+	wg sync.WaitGroup
+	// 共享的context，第一次失败时取消
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+	// 并发数限制，为空时不限制
+	sem chan struct{}
+	// 错误收集模式
+	mode GroupMode
+	// 失败时是否取消共享的context
+	cancelOnError bool
+
+	mu   sync.Mutex
+	errs *MultiError
+	// 第一个失败
+	first Error
+}
+
+type GroupOption func(g *Group)
+
+// WithGroupLimit 设置最大并发数，小于等于0时不限制
+func WithGroupLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithGroupMode 设置错误收集模式，默认为GroupCollectAll
+func WithGroupMode(mode GroupMode) GroupOption {
+	return func(g *Group) {
+		g.mode = mode
+	}
+}
+
+// WithGroupCancelOnError 设置失败时是否取消共享的context，默认取消
+func WithGroupCancelOnError(cancel bool) GroupOption {
+	return func(g *Group) {
+		g.cancelOnError = cancel
+	}
+}
+
+// NewGroup 创建Group，返回的context在第一次失败或Wait返回时被取消
+func NewGroup(ctx context.Context, opts ...GroupOption) (*Group, context.Context) {
+	g := &Group{
+		cancelOnError: true,
+		errs:          NewMultiError(AggregateWorst),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	g.ctx, g.cancel = context.WithCancelCause(ctx)
+	return g, g.ctx
+}
+
+// Go 在新的goroutine中执行fn，达到并发数限制时阻塞直到有空位
+func (g *Group) Go(fn func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.start(fn)
+}
+
+// TryGo 在未达到并发数限制时执行fn并返回true，否则返回false
+func (g *Group) TryGo(fn func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+
+	g.start(fn)
+	return true
+}
+
+// start 启动goroutine执行fn，需要已经占用并发数
+func (g *Group) start(fn func() error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.done()
+
+		var err error
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					err = handlePanic(r, nil, nil)
+				}
+			}()
+
+			err = fn()
+		}()
+
+		g.record(err)
+	}()
+}
+
+// done 释放并发数
+func (g *Group) done() {
+	if g.sem != nil {
+		<-g.sem
+	}
+	g.wg.Done()
+}
+
+// record 记录失败，由Group自身取消导致的context.Canceled不会被记录
+func (g *Group) record(err error) {
+	if err == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.first != nil && errors.Is(err, context.Canceled) && context.Cause(g.ctx) == g.first {
+		return
+	}
+
+	e := asError(err)
+	if g.first == nil {
+		g.first = e
+		if g.cancelOnError {
+			g.cancel(e)
+		}
+	}
+
+	if g.mode == GroupCollectAll {
+		g.errs.Append(e)
+	}
+}
+
+// Wait 等待所有函数返回，没有失败时返回nil。GroupCollectAll模式返回包含所有失败的MultiError，
+// HttpStatus为最严重的失败的状态码；GroupFirstOnly模式返回第一个失败
+func (g *Group) Wait() Error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.mode == GroupFirstOnly {
+		return g.first
+	}
+
+	return g.errs.ErrorOrNil()
+}
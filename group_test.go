@@ -0,0 +1,128 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGroup_CollectAll 测试收集所有失败并按最严重的失败返回状态码
+func TestGroup_CollectAll(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), WithGroupCancelOnError(false))
+	g.Go(func() error { return FastNew(ErrNotFound) })
+	g.Go(func() error { return FastNew(ErrExternal) })
+	g.Go(func() error { panic("worker crashed") })
+	g.Go(func() error { return nil })
+
+	err := g.Wait()
+	var multi *MultiError
+	if !errors.As(err, &multi) || multi.Len() != 3 {
+		t.Fatalf("Wait() = %v, want 3 errors", err)
+	}
+	if err.HttpStatus() != http.StatusBadGateway {
+		t.Errorf("HttpStatus() = %d, want %d", err.HttpStatus(), http.StatusBadGateway)
+	}
+
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value() != "worker crashed" {
+		t.Errorf("panic应该被恢复为PanicError, err = %v", err)
+	}
+	if ctx.Err() == nil {
+		t.Error("Wait返回后context应该被取消")
+	}
+}
+
+// TestGroup_Cancel 测试失败时取消共享的context，取消导致的错误不被收集
+func TestGroup_Cancel(t *testing.T) {
+	g, ctx := NewGroup(context.Background())
+	g.Go(func() error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.Go(func() error { return FastNew(ErrTimeout) })
+
+	err := g.Wait()
+	var multi *MultiError
+	if !errors.As(err, &multi) || multi.Len() != 1 || err.Code() != ErrTimeout.Code {
+		t.Fatalf("Wait() = %v, want only TIMEOUT", err)
+	}
+
+	cause := context.Cause(ctx)
+	if !errors.Is(cause, err.(*MultiError).Errors()[0]) {
+		t.Errorf("context.Cause() = %v", cause)
+	}
+}
+
+// TestGroup_FirstOnly 测试只保留第一个失败
+func TestGroup_FirstOnly(t *testing.T) {
+	g, _ := NewGroup(context.Background(), WithGroupMode(GroupFirstOnly), WithGroupLimit(1))
+	g.Go(func() error { return errors.New("first") })
+	g.Go(func() error { return FastNew(ErrInternal) })
+
+	err := g.Wait()
+	if err == nil || err.Error() != "Internal Server Error:first" {
+		t.Errorf("Wait() = %v, want first error", err)
+	}
+
+	g, _ = NewGroup(context.Background(), WithGroupMode(GroupFirstOnly))
+	g.Go(func() error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil", err)
+	}
+}
+
+// TestGroup_Limit 测试并发数限制
+func TestGroup_Limit(t *testing.T) {
+	g, _ := NewGroup(context.Background(), WithGroupLimit(2))
+
+	var running, peak atomic.Int32
+	for range 8 {
+		g.Go(func() error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		t.Fatalf("Wait() = %v", err)
+	}
+	if peak.Load() > 2 {
+		t.Errorf("peak = %d, want <= 2", peak.Load())
+	}
+
+	block := make(chan struct{})
+	g, _ = NewGroup(context.Background(), WithGroupLimit(1))
+	if !g.TryGo(func() error { <-block; return nil }) {
+		t.Fatal("第一次TryGo应该成功")
+	}
+	if g.TryGo(func() error { return nil }) {
+		t.Error("达到并发数限制时TryGo应该返回false")
+	}
+	close(block)
+	_ = g.Wait()
+}
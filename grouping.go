@@ -0,0 +1,211 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrorGroup 相同指纹的错误分组
+type ErrorGroup struct {
+	// 指纹
+	Fingerprint string `json:"fingerprint"`
+	// 错误码
+	Code string `json:"code"`
+	// 错误类型
+	Type ErrType `json:"type"`
+	// 分组内最高的严重程度
+	Severity Severity `json:"severity"`
+	// 出现次数
+	Count int64 `json:"count"`
+	// 第一次出现的时间
+	FirstSeen time.Time `json:"firstSeen"`
+	// 最后一次出现的时间
+	LastSeen time.Time `json:"lastSeen"`
+	// 最近的错误实例，按时间从旧到新排序
+	Samples []Error `json:"-"`
+}
+
+// GroupingStore 按指纹对错误分组，统计出现次数、首次和最近出现时间，并保留最近的错误实例
+type GroupingStore struct {
+	mu sync.Mutex
+	// 指纹计算器
+	fingerprinter *Fingerprinter
+	// 时钟
+	clock Clock
+	// 每个分组保留的错误实例数
+	maxSamples int
+	// 最多保留的分组数，超出时淘汰最久未出现的分组
+	maxGroups int
+	// 指纹 -> 分组
+	groups map[string]*ErrorGroup
+}
+
+type GroupingOption func(s *GroupingStore)
+
+// WithGroupingFingerprinter 设置指纹计算器，默认为DefaultFingerprinter
+func WithGroupingFingerprinter(f *Fingerprinter) GroupingOption {
+	return func(s *GroupingStore) {
+		s.fingerprinter = f
+	}
+}
+
+// WithGroupingClock 设置时钟，默认为系统时钟
+func WithGroupingClock(clock Clock) GroupingOption {
+	return func(s *GroupingStore) {
+		s.clock = clock
+	}
+}
+
+// WithMaxSamples 设置每个分组保留的错误实例数，默认5，0表示不保留
+func WithMaxSamples(n int) GroupingOption {
+	return func(s *GroupingStore) {
+		s.maxSamples = max(0, n)
+	}
+}
+
+// WithMaxGroups 设置最多保留的分组数，默认1000，小于等于0时不限制
+func WithMaxGroups(n int) GroupingOption {
+	return func(s *GroupingStore) {
+		s.maxGroups = n
+	}
+}
+
+// NewGroupingStore 创建错误分组存储
+func NewGroupingStore(opts ...GroupingOption) *GroupingStore {
+	s := &GroupingStore{
+		fingerprinter: DefaultFingerprinter,
+		clock:         SystemClock{},
+		maxSamples:    5,
+		maxGroups:     1000,
+		groups:        make(map[string]*ErrorGroup),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add 记录一次错误，返回错误的指纹以及是否为新的分组，err为nil时返回空指纹
+func (s *GroupingStore) Add(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+
+	e := asError(err)
+	fingerprint := s.fingerprinter.Fingerprint(e)
+	severity := SeverityOf(e)
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[fingerprint]
+	if !ok {
+		s.evict()
+		group = &ErrorGroup{
+			Fingerprint: fingerprint,
+			Code:        e.Code(),
+			Type:        e.Type(),
+			FirstSeen:   now,
+		}
+		s.groups[fingerprint] = group
+	}
+
+	group.Count++
+	group.LastSeen = now
+	group.Severity = max(group.Severity, severity)
+	if s.maxSamples > 0 {
+		if len(group.Samples) >= s.maxSamples {
+			group.Samples = append(group.Samples[:0], group.Samples[1:]...)
+		}
+		group.Samples = append(group.Samples, e)
+	}
+
+	return fingerprint, !ok
+}
+
+// evict 分组数达到上限时淘汰最久未出现的分组，需要持有锁
+func (s *GroupingStore) evict() {
+	if s.maxGroups <= 0 || len(s.groups) < s.maxGroups {
+		return
+	}
+
+	var oldest *ErrorGroup
+	for _, group := range s.groups {
+		if oldest == nil || group.LastSeen.Before(oldest.LastSeen) {
+			oldest = group
+		}
+	}
+	delete(s.groups, oldest.Fingerprint)
+}
+
+// Get 返回指纹对应分组的副本
+func (s *GroupingStore) Get(fingerprint string) (ErrorGroup, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	group, ok := s.groups[fingerprint]
+	if !ok {
+		return ErrorGroup{}, false
+	}
+
+	return group.clone(), true
+}
+
+// Groups 返回所有分组的副本，按出现次数从多到少排序，次数相同时最近出现的在前
+func (s *GroupingStore) Groups() []ErrorGroup {
+	s.mu.Lock()
+	groups := make([]ErrorGroup, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, group.clone())
+	}
+	s.mu.Unlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].LastSeen.After(groups[j].LastSeen)
+	})
+
+	return groups
+}
+
+// Len 返回分组数
+func (s *GroupingStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.groups)
+}
+
+// Reset 清空所有分组
+func (s *GroupingStore) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clear(s.groups)
+}
+
+// clone 复制分组，错误实例列表不与存储共享
+func (g *ErrorGroup) clone() ErrorGroup {
+	c := *g
+	c.Samples = append([]Error(nil), g.Samples...)
+	return c
+}
@@ -0,0 +1,88 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"testing"
+	"time"
+)
+
+// TestGroupingStore 测试按指纹分组统计
+func TestGroupingStore(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	s := NewGroupingStore(WithGroupingClock(clock), WithMaxSamples(2))
+
+	var fingerprint string
+	for i := range 3 {
+		fp, isNew := s.Add(loadUser(i))
+		if isNew != (i == 0) {
+			t.Errorf("第%d次 isNew = %v", i, isNew)
+		}
+		fingerprint = fp
+		clock.Advance(time.Second)
+	}
+	if _, isNew := s.Add(FastNew(ErrTimeout)); !isNew {
+		t.Error("不同的错误应该创建新的分组")
+	}
+	if fp, _ := s.Add(nil); fp != "" {
+		t.Error("nil不应该被记录")
+	}
+
+	group, ok := s.Get(fingerprint)
+	if !ok {
+		t.Fatal("分组不存在")
+	}
+	if group.Count != 3 || group.Code != ErrNotFound.Code || group.Severity != SeverityInfo {
+		t.Errorf("group = %+v", group)
+	}
+	if !group.FirstSeen.Equal(start) || !group.LastSeen.Equal(start.Add(2*time.Second)) {
+		t.Errorf("firstSeen = %v, lastSeen = %v", group.FirstSeen, group.LastSeen)
+	}
+	if len(group.Samples) != 2 || group.Samples[1].Message() != "user 2 not found" {
+		t.Errorf("samples = %v", group.Samples)
+	}
+
+	groups := s.Groups()
+	if len(groups) != 2 || groups[0].Fingerprint != fingerprint {
+		t.Errorf("Groups() = %+v", groups)
+	}
+
+	s.Reset()
+	if s.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", s.Len())
+	}
+}
+
+// TestGroupingStore_Evict 测试分组数达到上限时淘汰最久未出现的分组
+func TestGroupingStore_Evict(t *testing.T) {
+	clock := newFakeClock()
+	s := NewGroupingStore(WithGroupingClock(clock), WithMaxGroups(2))
+
+	first, _ := s.Add(FastNew(ErrNotFound))
+	clock.Advance(time.Second)
+	second, _ := s.Add(FastNew(ErrTimeout))
+	clock.Advance(time.Second)
+	s.Add(FastNew(ErrNotFound))
+	clock.Advance(time.Second)
+	s.Add(FastNew(ErrInternal))
+
+	if _, ok := s.Get(second); ok {
+		t.Error("最久未出现的分组应该被淘汰")
+	}
+	if _, ok := s.Get(first); !ok || s.Len() != 2 {
+		t.Errorf("Len() = %d", s.Len())
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 重试提示在元数据中的键名，时长类的值可以是time.Duration、秒数或时长字符串
+const (
+	// MetadataRetryAfter 建议的重试等待时间
+	MetadataRetryAfter = "retry_after"
+	// MetadataRateLimitLimit 限流窗口内允许的请求数
+	MetadataRateLimitLimit = "ratelimit_limit"
+	// MetadataRateLimitRemaining 限流窗口内剩余的请求数
+	MetadataRateLimitRemaining = "ratelimit_remaining"
+	// MetadataRateLimitReset 距离限流窗口重置的时间
+	MetadataRateLimitReset = "ratelimit_reset"
+)
+
+// 重试提示相关的响应头
+const (
+	HeaderRetryAfter         = "Retry-After"
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+)
+
+// RetryHint 错误携带的重试提示
+type RetryHint struct {
+	// 建议的重试等待时间，0表示未知
+	RetryAfter time.Duration
+	// 限流窗口内允许的请求数，0表示未知
+	Limit int
+	// 限流窗口内剩余的请求数，仅在Limit大于0时有效
+	Remaining int
+	// 距离限流窗口重置的时间，0表示未知
+	Reset time.Duration
+}
+
+// IsZero 是否不包含任何提示
+func (h RetryHint) IsZero() bool {
+	return h.RetryAfter <= 0 && h.Limit <= 0 && h.Reset <= 0
+}
+
+// RetryHinter 可以直接提供重试提示的错误，未实现时从元数据中读取
+type RetryHinter interface {
+	RetryHint() RetryHint
+}
+
+// WithRetryHint 将重试提示写入错误的元数据
+func WithRetryHint(err Error, hint RetryHint) Error {
+	if hint.RetryAfter > 0 {
+		err.WithMetadata(MetadataRetryAfter, hint.RetryAfter)
+	}
+	if hint.Limit > 0 {
+		err.WithMetadata(MetadataRateLimitLimit, hint.Limit)
+		err.WithMetadata(MetadataRateLimitRemaining, hint.Remaining)
+	}
+	if hint.Reset > 0 {
+		err.WithMetadata(MetadataRateLimitReset, hint.Reset)
+	}
+
+	return err
+}
+
+// GetRetryHint 返回错误携带的重试提示，优先使用RetryHinter接口，其次读取元数据
+func GetRetryHint(err error) (RetryHint, bool) {
+	var hinter RetryHinter
+	if errors.As(err, &hinter) {
+		hint := hinter.RetryHint()
+		return hint, !hint.IsZero()
+	}
+
+	var e Error
+	if !errors.As(err, &e) {
+		return RetryHint{}, false
+	}
+
+	metadata := e.Metadata()
+	var hint RetryHint
+	hint.RetryAfter, _ = metadataDuration(metadata[MetadataRetryAfter])
+	hint.Reset, _ = metadataDuration(metadata[MetadataRateLimitReset])
+	if limit, ok := metadataInt(metadata[MetadataRateLimitLimit]); ok {
+		hint.Limit = limit
+		hint.Remaining, _ = metadataInt(metadata[MetadataRateLimitRemaining])
+	}
+
+	return hint, !hint.IsZero()
+}
+
+// writeRetryHeaders 将重试提示写入响应头
+func writeRetryHeaders(header http.Header, hint RetryHint) {
+	if hint.RetryAfter > 0 {
+		header.Set(HeaderRetryAfter, strconv.Itoa(ceilSeconds(hint.RetryAfter)))
+	}
+	if hint.Limit > 0 {
+		header.Set(HeaderRateLimitLimit, strconv.Itoa(hint.Limit))
+		header.Set(HeaderRateLimitRemaining, strconv.Itoa(hint.Remaining))
+	}
+	if hint.Reset > 0 {
+		header.Set(HeaderRateLimitReset, strconv.Itoa(ceilSeconds(hint.Reset)))
+	}
+}
+
+// readRetryHeaders 从响应头中读取重试提示，同时兼容X-RateLimit-*格式
+func readRetryHeaders(header http.Header, now time.Time) RetryHint {
+	var hint RetryHint
+	if v := header.Get(HeaderRetryAfter); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			hint.RetryAfter = time.Duration(seconds) * time.Second
+		} else if at, err := http.ParseTime(v); err == nil && at.After(now) {
+			hint.RetryAfter = at.Sub(now)
+		}
+	}
+
+	if limit, ok := headerInt(header, HeaderRateLimitLimit, "X-RateLimit-Limit"); ok {
+		hint.Limit = limit
+		hint.Remaining, _ = headerInt(header, HeaderRateLimitRemaining, "X-RateLimit-Remaining")
+	}
+	if reset, ok := headerInt(header, HeaderRateLimitReset); ok {
+		hint.Reset = time.Duration(reset) * time.Second
+	}
+
+	return hint
+}
+
+// headerInt 按顺序读取第一个存在的整数响应头
+func headerInt(header http.Header, keys ...string) (int, bool) {
+	for _, key := range keys {
+		if v := header.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			return n, err == nil
+		}
+	}
+
+	return 0, false
+}
+
+// ceilSeconds 将时长向上取整为秒，最小为1秒
+func ceilSeconds(d time.Duration) int {
+	return max(1, int(math.Ceil(d.Seconds())))
+}
+
+// metadataDuration 解析元数据中的时长
+func metadataDuration(val any) (time.Duration, bool) {
+	switch v := val.(type) {
+	case time.Duration:
+		return v, true
+	case int:
+		return time.Duration(v) * time.Second, true
+	case int64:
+		return time.Duration(v) * time.Second, true
+	case float64:
+		return time.Duration(v * float64(time.Second)), true
+	case string:
+		if d, err := time.ParseDuration(v); err == nil {
+			return d, true
+		}
+		if seconds, err := strconv.Atoi(v); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	return 0, false
+}
+
+// metadataInt 解析元数据中的整数
+func metadataInt(val any) (int, bool) {
+	switch v := val.(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	case string:
+		n, err := strconv.Atoi(v)
+		return n, err == nil
+	}
+
+	return 0, false
+}
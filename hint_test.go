@@ -0,0 +1,138 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type hintedError struct {
+	*ErrorImpl
+}
+
+func (e hintedError) RetryHint() RetryHint {
+	return RetryHint{RetryAfter: 7 * time.Second}
+}
+
+// TestGetRetryHint 测试从接口和元数据中读取重试提示
+func TestGetRetryHint(t *testing.T) {
+	err := WithRetryHint(FastNew(ErrRateLimit), RetryHint{
+		RetryAfter: 1500 * time.Millisecond,
+		Limit:      100,
+		Remaining:  0,
+		Reset:      30 * time.Second,
+	})
+
+	hint, ok := GetRetryHint(err)
+	want := RetryHint{RetryAfter: 1500 * time.Millisecond, Limit: 100, Remaining: 0, Reset: 30 * time.Second}
+	if !ok || hint != want {
+		t.Errorf("GetRetryHint() = %+v, %v, want %+v", hint, ok, want)
+	}
+
+	if d, ok := RetryAfter(hintedError{FastNew(ErrTimeout).(*ErrorImpl)}); !ok || d != 7*time.Second {
+		t.Errorf("RetryAfter() = %v, %v, want 7s", d, ok)
+	}
+
+	if _, ok := GetRetryHint(FastNew(ErrRateLimit)); ok {
+		t.Error("没有重试提示时应该返回false")
+	}
+}
+
+// TestHandler_RetryHeaders 测试响应头的写入和客户端的读取
+func TestHandler_RetryHeaders(t *testing.T) {
+	h := NewHandler(nil, WithDefaultRetryAfter(2*time.Second))
+	engine := gin.New()
+	engine.Use(h.ErrorMiddleware())
+	engine.GET("/limited", func(c *gin.Context) {
+		_ = c.Error(WithRetryHint(FastNew(ErrRateLimit), RetryHint{
+			RetryAfter: 1500 * time.Millisecond,
+			Limit:      100,
+			Remaining:  0,
+			Reset:      30 * time.Second,
+		}))
+	})
+	engine.GET("/timeout", func(c *gin.Context) {
+		_ = c.Error(FastNew(ErrTimeout))
+	})
+	engine.GET("/missing", func(c *gin.Context) {
+		_ = c.Error(FastNew(ErrNotFound))
+	})
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	tests := []struct {
+		path        string
+		wantHeaders map[string]string
+		wantHint    RetryHint
+	}{
+		{
+			path: "/limited",
+			wantHeaders: map[string]string{
+				HeaderRetryAfter:         "2",
+				HeaderRateLimitLimit:     "100",
+				HeaderRateLimitRemaining: "0",
+				HeaderRateLimitReset:     "30",
+			},
+			wantHint: RetryHint{RetryAfter: 2 * time.Second, Limit: 100, Remaining: 0, Reset: 30 * time.Second},
+		},
+		{
+			path:        "/timeout",
+			wantHeaders: map[string]string{HeaderRetryAfter: "2"},
+			wantHint:    RetryHint{RetryAfter: 2 * time.Second},
+		},
+		{
+			path:        "/missing",
+			wantHeaders: map[string]string{HeaderRetryAfter: ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := srv.Client().Get(srv.URL + tt.path)
+			if err != nil {
+				t.Fatalf("请求失败: %v", err)
+			}
+			defer resp.Body.Close()
+
+			for key, want := range tt.wantHeaders {
+				if got := resp.Header.Get(key); got != want {
+					t.Errorf("%s = %q, want %q", key, got, want)
+				}
+			}
+
+			decoded := DecodeResponse(resp)
+			hint, _ := GetRetryHint(decoded)
+			if hint != tt.wantHint {
+				t.Errorf("decoded hint = %+v, want %+v", hint, tt.wantHint)
+			}
+		})
+	}
+
+	// 标准库的请求头写法
+	header := http.Header{}
+	header.Set(HeaderRetryAfter, time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	header.Set("X-RateLimit-Limit", "10")
+	header.Set("X-RateLimit-Remaining", "3")
+	hint := readRetryHeaders(header, time.Now())
+	if hint.RetryAfter <= 58*time.Second || hint.Limit != 10 || hint.Remaining != 3 {
+		t.Errorf("readRetryHeaders() = %+v", hint)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"runtime/debug"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// CreateHook 错误创建时调用的钩子，New、Wrap、Builder.Build等创建的每个Error都会触发，
+// 在构造函数内部的元数据（如分类规则、下游错误码）写入之后调用，收到的是最外层的值，
+// 如*ValidationError和*PanicError，构造函数返回后调用方再追加的元数据在钩子中不可见
+type CreateHook func(err Error)
+
+// WrapHook 包装标准error时调用的钩子，在CreateHook之后触发
+type WrapHook func(cause error, wrapped Error)
+
+// hookEntry 注册的钩子，id用于移除
+type hookEntry[T any] struct {
+	id uint64
+	fn T
+}
+
+// hookSet 钩子集合的不可变快照，修改时整体替换，读取时不需要加锁
+type hookSet struct {
+	create []hookEntry[CreateHook]
+	wrap   []hookEntry[WrapHook]
+}
+
+var (
+	// hooks 当前生效的钩子
+	hooks atomic.Pointer[hookSet]
+	// hooksMu 串行化钩子的修改
+	hooksMu sync.Mutex
+	// hookID 钩子的自增id
+	hookID atomic.Uint64
+)
+
+// OnCreate 注册错误创建钩子，返回移除该钩子的函数
+func OnCreate(fn CreateHook) (remove func()) {
+	id := hookID.Add(1)
+	updateHooks(func(s *hookSet) {
+		s.create = append(s.create, hookEntry[CreateHook]{id: id, fn: fn})
+	})
+
+	return func() {
+		updateHooks(func(s *hookSet) {
+			s.create = slices.DeleteFunc(s.create, func(e hookEntry[CreateHook]) bool { return e.id == id })
+		})
+	}
+}
+
+// OnWrap 注册包装钩子，返回移除该钩子的函数
+func OnWrap(fn WrapHook) (remove func()) {
+	id := hookID.Add(1)
+	updateHooks(func(s *hookSet) {
+		s.wrap = append(s.wrap, hookEntry[WrapHook]{id: id, fn: fn})
+	})
+
+	return func() {
+		updateHooks(func(s *hookSet) {
+			s.wrap = slices.DeleteFunc(s.wrap, func(e hookEntry[WrapHook]) bool { return e.id == id })
+		})
+	}
+}
+
+// IsolateHooks 清空当前所有钩子，返回恢复之前钩子的函数，测试中用来隔离全局注册的钩子。
+// 钩子是进程级的全局状态，隔离期间其它goroutine创建的错误也不会触发钩子，
+// 因此使用IsolateHooks或注册钩子的测试不能调用t.Parallel()
+//
+//	defer errors.IsolateHooks()()
+func IsolateHooks() (restore func()) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	prev := hooks.Swap(nil)
+	return func() {
+		hooksMu.Lock()
+		defer hooksMu.Unlock()
+
+		hooks.Store(prev)
+	}
+}
+
+// updateHooks 复制当前的钩子集合，修改后整体替换
+func updateHooks(fn func(s *hookSet)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	next := &hookSet{}
+	if cur := hooks.Load(); cur != nil {
+		next.create = slices.Clone(cur.create)
+		next.wrap = slices.Clone(cur.wrap)
+	}
+	fn(next)
+
+	if len(next.create) == 0 && len(next.wrap) == 0 {
+		hooks.Store(nil)
+		return
+	}
+	hooks.Store(next)
+}
+
+// fireCreate 调用错误创建钩子
+func fireCreate(err Error) {
+	s := hooks.Load()
+	if s == nil {
+		return
+	}
+
+	for _, e := range s.create {
+		callHook(func() { e.fn(err) })
+	}
+}
+
+// fireWrap 调用错误创建钩子和包装钩子
+func fireWrap(cause error, wrapped Error) {
+	s := hooks.Load()
+	if s == nil {
+		return
+	}
+
+	for _, e := range s.create {
+		callHook(func() { e.fn(wrapped) })
+	}
+	for _, e := range s.wrap {
+		callHook(func() { e.fn(cause, wrapped) })
+	}
+}
+
+// callHook 调用单个钩子，钩子的panic不会影响错误的创建和其它钩子，
+// 恢复后的PanicError交给SetPanicHandler设置的全局处理函数，未设置时与SafeGo一样使用DefaultPanicHandler，
+// 且不会再次触发钩子
+func callHook(fn func()) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		handle := DefaultPanicHandler
+		if handler := globalPanicHandler.Load(); handler != nil {
+			handle = *handler
+		}
+
+		err := newPanicError(r, debug.Stack())
+		defer func() { _ = recover() }()
+		handle(err)
+	}()
+
+	fn()
+}
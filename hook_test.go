@@ -0,0 +1,215 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestHooks 测试各个构造函数都会触发钩子
+func TestHooks(t *testing.T) {
+	defer IsolateHooks()()
+
+	var created []string
+	var wrapped []error
+	removeCreate := OnCreate(func(err Error) {
+		created = append(created, err.Code())
+	})
+	removeWrap := OnWrap(func(cause error, err Error) {
+		wrapped = append(wrapped, cause)
+	})
+
+	_ = New(ErrNotFound)
+	_ = FastNewf(ErrTimeout, "slow %s", "db")
+	_ = FastNewWith(ErrBadRequest, Params{"field": "name"})
+	_ = NewBuilder().WithCode(ErrConflict).Build()
+	_ = Wrap(io.EOF, ErrInternal)
+	_ = FastWrapf("read: %s", io.ErrUnexpectedEOF, ErrExternal)
+	_ = NewBuilder().WithCode(ErrExternal).WithCause(io.ErrClosedPipe).Build()
+
+	// 已经是Error时Wrap直接返回，不会创建新的错误
+	_ = Wrap(FastNew(ErrForbidden), ErrInternal)
+
+	want := []string{
+		ErrNotFound.Code, ErrTimeout.Code, ErrBadRequest.Code, ErrConflict.Code,
+		ErrInternal.Code, ErrExternal.Code, ErrExternal.Code, ErrForbidden.Code,
+	}
+	if len(created) != len(want) {
+		t.Fatalf("created = %v, want %v", created, want)
+	}
+	for i := range want {
+		if created[i] != want[i] {
+			t.Errorf("created[%d] = %s, want %s", i, created[i], want[i])
+		}
+	}
+
+	wantCauses := []error{io.EOF, io.ErrUnexpectedEOF, io.ErrClosedPipe}
+	if len(wrapped) != len(wantCauses) {
+		t.Fatalf("wrapped = %v, want %v", wrapped, wantCauses)
+	}
+	for i := range wantCauses {
+		if wrapped[i] != wantCauses[i] {
+			t.Errorf("wrapped[%d] = %v, want %v", i, wrapped[i], wantCauses[i])
+		}
+	}
+
+	removeCreate()
+	removeWrap()
+	_ = Wrap(io.EOF, ErrInternal)
+	if len(created) != len(want) || len(wrapped) != len(wantCauses) {
+		t.Error("移除后钩子不应该再被调用")
+	}
+}
+
+// TestHooks_PanicIsolation 测试钩子的panic不影响错误创建和其它钩子
+func TestHooks_PanicIsolation(t *testing.T) {
+	defer IsolateHooks()()
+
+	var recovered []*PanicError
+	defer SetPanicHandler(func(err *PanicError) {
+		recovered = append(recovered, err)
+	})()
+
+	calls := 0
+	OnCreate(func(Error) { panic("bad hook") })
+	OnCreate(func(Error) { calls++ })
+
+	err := FastNew(ErrNotFound)
+	if err == nil || err.Code() != ErrNotFound.Code {
+		t.Fatalf("FastNew() = %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("calls = %d, want 1", calls)
+	}
+	if len(recovered) != 1 || recovered[0].Value() != "bad hook" {
+		t.Errorf("recovered = %v", recovered)
+	}
+}
+
+// TestHooks_PanicDefaultHandler 测试未设置全局处理函数时钩子的panic使用DefaultPanicHandler记录
+func TestHooks_PanicDefaultHandler(t *testing.T) {
+	defer IsolateHooks()()
+	defer SetPanicHandler(nil)()
+	core, logs := observer.New(zapcore.ErrorLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	OnCreate(func(Error) { panic("bad hook") })
+	_ = FastNew(ErrNotFound)
+
+	if logs.Len() != 1 || !strings.Contains(fmt.Sprint(logs.All()[0].ContextMap()["error"]), "bad hook") {
+		t.Errorf("logs = %+v", logs.All())
+	}
+}
+
+// TestIsolateHooks 测试隔离和恢复钩子
+func TestIsolateHooks(t *testing.T) {
+	defer IsolateHooks()()
+
+	calls := 0
+	OnCreate(func(Error) { calls++ })
+
+	restore := IsolateHooks()
+	_ = FastNew(ErrInternal)
+	if calls != 0 {
+		t.Error("隔离后外层的钩子不应该被调用")
+	}
+	restore()
+
+	_ = FastNew(ErrInternal)
+	if calls != 1 {
+		t.Errorf("恢复后 calls = %d, want 1", calls)
+	}
+}
+
+// TestHooks_Concurrent 测试并发注册和触发钩子
+func TestHooks_Concurrent(t *testing.T) {
+	defer IsolateHooks()()
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			remove := OnCreate(func(Error) {})
+			remove()
+		}()
+		go func() {
+			defer wg.Done()
+			_ = FastWrap(errors.New("x"), ErrInternal)
+		}()
+	}
+	wg.Wait()
+}
+
+// TestHooks_CompleteValue 测试钩子在构造完成后触发，收到外层类型和内部设置的元数据
+func TestHooks_CompleteValue(t *testing.T) {
+	defer IsolateHooks()()
+
+	var created, wrapped []Error
+	OnCreate(func(err Error) { created = append(created, err) })
+	OnWrap(func(_ error, err Error) { wrapped = append(wrapped, err) })
+
+	_ = NewValidationError(FieldError{Field: "name", Rule: "required", Message: "name is required"})
+	if len(created) != 1 {
+		t.Fatalf("created = %d, want 1", len(created))
+	}
+	ve, ok := created[0].(*ValidationError)
+	if !ok {
+		t.Fatalf("创建钩子收到 %T, want *ValidationError", created[0])
+	}
+	if len(ve.Fields()) != 1 {
+		t.Errorf("钩子中 Fields() = %v, want 1个字段", ve.Fields())
+	}
+
+	_ = FastWrapAuto(context.Canceled)
+	if len(wrapped) != 1 {
+		t.Fatalf("wrapped = %d, want 1", len(wrapped))
+	}
+	if _, ok := wrapped[0].Metadata()[MetadataClassifiedBy]; !ok {
+		t.Errorf("包装钩子中缺少 %s 元数据", MetadataClassifiedBy)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		NewHandler(nil).WriteError(w, r, FastNew(ErrNotFound))
+	}))
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("请求失败: %v", err)
+	}
+	defer resp.Body.Close()
+
+	created = created[:0]
+	_ = DecodeResponse(resp)
+	if len(created) != 1 {
+		t.Fatalf("created = %d, want 1", len(created))
+	}
+	if got := created[0].Metadata()["remote_code"]; got != ErrNotFound.Code {
+		t.Errorf("钩子中 remote_code = %v, want %s", got, ErrNotFound.Code)
+	}
+}
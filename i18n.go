@@ -0,0 +1,307 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale 默认的兜底语言
+const DefaultLocale = "en"
+
+// Catalog 错误信息的翻译目录，按语言和消息键保存消息模板，消息键为ErrCode.MessageKey，未设置时为Code，
+// 模板中可以使用 {name} 形式的命名占位符，渲染时从错误的元数据中取值
+type Catalog struct {
+	mu sync.RWMutex
+	// 语言 -> 消息键 -> 消息模板，语言标签已归一化为小写
+	messages map[string]map[string]string
+	// 兜底语言
+	fallback string
+}
+
+// NewCatalog 创建翻译目录，fallback为兜底语言，为空时使用DefaultLocale
+func NewCatalog(fallback string) *Catalog {
+	if fallback == "" {
+		fallback = DefaultLocale
+	}
+
+	return &Catalog{
+		messages: make(map[string]map[string]string),
+		fallback: fallback,
+	}
+}
+
+// Add 添加单条翻译
+func (c *Catalog) Add(locale, code, template string) *Catalog {
+	return c.AddMessages(locale, map[string]string{code: template})
+}
+
+// AddMessages 批量添加某个语言的翻译，key为消息键
+func (c *Catalog) AddMessages(locale string, messages map[string]string) *Catalog {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locale = normalizeLocale(locale)
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string, len(messages))
+	}
+	for code, template := range messages {
+		c.messages[locale][code] = template
+	}
+
+	return c
+}
+
+// Fallback 返回兜底语言
+func (c *Catalog) Fallback() string {
+	return c.fallback
+}
+
+// Lookup 沿语言回退链查找消息键的消息模板，返回模板和命中的语言
+func (c *Catalog) Lookup(locale, key string) (string, string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, candidate := range c.chain(locale) {
+		if template, ok := c.messages[normalizeLocale(candidate)][key]; ok {
+			return template, candidate, true
+		}
+	}
+
+	return "", "", false
+}
+
+// Match 按优先级从候选语言中选出目录支持的语言，都不支持时返回兜底语言
+func (c *Catalog) Match(locales ...string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, locale := range locales {
+		for _, candidate := range LocaleChain(locale) {
+			if _, ok := c.messages[normalizeLocale(candidate)]; ok {
+				return candidate
+			}
+		}
+	}
+
+	return c.fallback
+}
+
+// Localize 返回错误在指定语言下的消息，占位符从错误的元数据中取值，
+// 按错误的消息键查找翻译，目录中没有该消息键的翻译时返回PublicMessage
+func (c *Catalog) Localize(err error, locale string) string {
+	var e Error
+	if !errors.As(err, &e) {
+		if err == nil {
+			return ""
+		}
+		return err.Error()
+	}
+
+	template, _, ok := c.Lookup(locale, messageKeyOf(e))
+	if !ok {
+		return e.PublicMessage()
+	}
+
+	return renderTemplate(template, e.Metadata())
+}
+
+// Validate 校验目录中已注册错误码的翻译只引用了错误码声明的参数
+func (c *Catalog) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var errs []error
+	for locale, messages := range c.messages {
+		for key, template := range messages {
+			registered, ok := lookupMessageKey(key)
+			if !ok {
+				continue
+			}
+			if err := validateTemplate(template, registered.Params); err != nil {
+				errs = append(errs, fmt.Errorf("errors: catalog %s %s: %w", locale, key, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// messageKeyOf 返回错误在翻译目录中查找本地化消息使用的键，没有实现MessageKey的错误使用Code
+func messageKeyOf(e Error) string {
+	if k, ok := e.(interface{ MessageKey() string }); ok {
+		return k.MessageKey()
+	}
+
+	return e.Code()
+}
+
+// chain 返回语言的回退链，最后追加兜底语言
+func (c *Catalog) chain(locale string) []string {
+	chain := LocaleChain(locale)
+	for _, candidate := range chain {
+		if strings.EqualFold(candidate, c.fallback) {
+			return chain
+		}
+	}
+
+	return append(chain, c.fallback)
+}
+
+// LocaleChain 返回语言标签的回退链，例如 zh-Hant-TW → zh-Hant-TW, zh-Hant, zh
+// 不带文字标签的中文地区会补充对应的文字标签，例如 zh-TW → zh-TW, zh-Hant, zh
+func LocaleChain(locale string) []string {
+	locale = strings.ReplaceAll(strings.TrimSpace(locale), "_", "-")
+	if locale == "" || locale == "*" {
+		return nil
+	}
+
+	parts := strings.Split(locale, "-")
+	chain := make([]string, 0, len(parts)+1)
+	for i := len(parts); i > 0; i-- {
+		chain = append(chain, strings.Join(parts[:i], "-"))
+	}
+
+	if strings.EqualFold(parts[0], "zh") && len(parts) == 2 {
+		switch strings.ToUpper(parts[1]) {
+		case "TW", "HK", "MO":
+			chain = []string{chain[0], "zh-Hant", "zh"}
+		case "CN", "SG":
+			chain = []string{chain[0], "zh-Hans", "zh"}
+		}
+	}
+
+	return chain
+}
+
+// ParseAcceptLanguage 解析Accept-Language请求头，按权重从高到低返回语言标签
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		tag, q := part, 1.0
+		if i := strings.Index(part, ";"); i >= 0 {
+			tag = strings.TrimSpace(part[:i])
+			if param := strings.TrimSpace(part[i+1:]); strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+					q = v
+				}
+			}
+		}
+
+		if q <= 0 || tag == "" {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].q > tags[j].q
+	})
+
+	locales := make([]string, 0, len(tags))
+	for _, t := range tags {
+		locales = append(locales, t.tag)
+	}
+
+	return locales
+}
+
+// normalizeLocale 归一化语言标签，用作目录的key
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+}
+
+// DefaultCatalog 默认的翻译目录，内置了预定义错误码的英文和中文翻译
+var DefaultCatalog = NewCatalog(DefaultLocale).
+	AddMessages("en", map[string]string{
+		ErrInternal.Code:       ErrInternalMessage,
+		ErrTimeout.Code:        ErrTimeoutMessage,
+		ErrNotFound.Code:       ErrNotFoundMessage,
+		ErrBadRequest.Code:     ErrBadRequestMessage,
+		ErrUnauthorized.Code:   ErrUnauthorizedMessage,
+		ErrForbidden.Code:      ErrForbiddenMessage,
+		ErrConflict.Code:       ErrConflictMessage,
+		ErrRateLimit.Code:      ErrRateLimitMessage,
+		ErrExternal.Code:       ErrExternalMessage,
+		ErrValidation.Code:     ErrValidationMessage,
+		BusinessError.Code:     ErrBusinessMessage,
+		ErrPanicRecovered.Code: ErrPanicRecoveredMessage,
+		ErrCircuitOpen.Code:    ErrCircuitOpenMessage,
+		ErrCanceled.Code:       ErrCanceledMessage,
+
+		ErrUsernameExisted.MessageKey: ErrUsernameExistedMessage,
+		ErrEmailExisted.MessageKey:    ErrEmailExistedMessage,
+		ErrPhoneExisted.MessageKey:    ErrPhoneExistedMessage,
+	}).
+	AddMessages("zh", map[string]string{
+		ErrInternal.Code:       "服务器内部错误",
+		ErrTimeout.Code:        "请求超时",
+		ErrNotFound.Code:       "资源不存在",
+		ErrBadRequest.Code:     "请求参数错误",
+		ErrUnauthorized.Code:   "未认证",
+		ErrForbidden.Code:      "禁止访问",
+		ErrConflict.Code:       "资源冲突",
+		ErrRateLimit.Code:      "请求过于频繁",
+		ErrExternal.Code:       "上游服务错误",
+		ErrValidation.Code:     "参数校验失败",
+		BusinessError.Code:     "业务错误",
+		ErrPanicRecovered.Code: "服务器内部错误",
+		ErrCircuitOpen.Code:    "服务暂时不可用",
+		ErrCanceled.Code:       "请求已取消",
+
+		ErrUsernameExisted.MessageKey: "用户名已存在",
+		ErrEmailExisted.MessageKey:    "邮箱已存在",
+		ErrPhoneExisted.MessageKey:    "手机号已存在",
+	}).
+	AddMessages("zh-Hant", map[string]string{
+		ErrInternal.Code:       "伺服器內部錯誤",
+		ErrTimeout.Code:        "請求逾時",
+		ErrNotFound.Code:       "資源不存在",
+		ErrBadRequest.Code:     "請求參數錯誤",
+		ErrUnauthorized.Code:   "未認證",
+		ErrForbidden.Code:      "禁止存取",
+		ErrConflict.Code:       "資源衝突",
+		ErrRateLimit.Code:      "請求過於頻繁",
+		ErrExternal.Code:       "上游服務錯誤",
+		ErrValidation.Code:     "參數校驗失敗",
+		BusinessError.Code:     "業務錯誤",
+		ErrPanicRecovered.Code: "伺服器內部錯誤",
+		ErrCircuitOpen.Code:    "服務暫時無法使用",
+		ErrCanceled.Code:       "請求已取消",
+
+		ErrUsernameExisted.MessageKey: "使用者名稱已存在",
+		ErrEmailExisted.MessageKey:    "電子郵件已存在",
+		ErrPhoneExisted.MessageKey:    "手機號碼已存在",
+	})
+
+// Localize 使用DefaultCatalog返回错误在指定语言下的消息
+func Localize(err error, locale string) string {
+	return DefaultCatalog.Localize(err, locale)
+}
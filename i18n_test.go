@@ -0,0 +1,175 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// TestLocaleChain 测试语言回退链
+func TestLocaleChain(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{locale: "zh-Hant-TW", want: []string{"zh-Hant-TW", "zh-Hant", "zh"}},
+		{locale: "zh-TW", want: []string{"zh-TW", "zh-Hant", "zh"}},
+		{locale: "zh_CN", want: []string{"zh-CN", "zh-Hans", "zh"}},
+		{locale: "en-US", want: []string{"en-US", "en"}},
+		{locale: "*", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			if got := LocaleChain(tt.locale); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("LocaleChain(%s) = %v, want %v", tt.locale, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseAcceptLanguage 测试Accept-Language解析
+func TestParseAcceptLanguage(t *testing.T) {
+	got := ParseAcceptLanguage("en;q=0.5, zh-Hant-HK, zh;q=0.8, fr;q=0")
+	want := []string{"zh-Hant-HK", "zh", "en"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseAcceptLanguage() = %v, want %v", got, want)
+	}
+}
+
+// TestCatalog_Localize 测试翻译和命名占位符
+func TestCatalog_Localize(t *testing.T) {
+	code := &ErrCode{Code: "ORDER_NOT_FOUND", Message: "Order not found", HttpStatus: http.StatusNotFound, Type: ErrTypeNotFound}
+	c := NewCatalog("en").
+		Add("en", code.Code, "Order {order_id} not found").
+		Add("zh", code.Code, "订单 {order_id} 不存在")
+
+	err := New(code).WithMetadata("order_id", 42)
+	tests := []struct {
+		locale string
+		want   string
+	}{
+		{locale: "zh-Hant", want: "订单 42 不存在"},
+		{locale: "zh", want: "订单 42 不存在"},
+		{locale: "ja", want: "Order 42 not found"},
+		{locale: "", want: "Order 42 not found"},
+	}
+
+	for _, tt := range tests {
+		if got := c.Localize(err, tt.locale); got != tt.want {
+			t.Errorf("Localize(%q) = %s, want %s", tt.locale, got, tt.want)
+		}
+	}
+
+	// 没有翻译时返回PublicMessage
+	if got := c.Localize(New(ErrConflict), "zh"); got != ErrConflictMessage {
+		t.Errorf("Localize() = %s, want %s", got, ErrConflictMessage)
+	}
+}
+
+// TestHandler_Localize 测试HTTP响应根据Accept-Language返回本地化信息
+func TestHandler_Localize(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		err            Error
+		wantMsg        string
+		wantLanguage   string
+	}{
+		{
+			name:           "繁体中文",
+			acceptLanguage: "zh-TW,zh;q=0.9,en;q=0.8",
+			err:            New(ErrNotFound),
+			wantMsg:        "資源不存在",
+			wantLanguage:   "zh-Hant",
+		},
+		{
+			name:           "简体中文",
+			acceptLanguage: "zh-CN",
+			err:            New(ErrNotFound),
+			wantMsg:        "资源不存在",
+			wantLanguage:   "zh",
+		},
+		{
+			name:           "不支持的语言回退到英文",
+			acceptLanguage: "ja-JP",
+			err:            New(ErrNotFound),
+			wantMsg:        ErrNotFoundMessage,
+			wantLanguage:   "en",
+		},
+		{
+			name:           "自定义信息不翻译",
+			acceptLanguage: "zh",
+			err:            Newf(ErrNotFound, "user 1 not found"),
+			wantMsg:        "user 1 not found",
+			wantLanguage:   "zh",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			rec := httptest.NewRecorder()
+			NewHandler(nil).WriteError(rec, req, tt.err)
+
+			if resp := decodeErrorResponse(t, rec); resp.Message != tt.wantMsg {
+				t.Errorf("Message = %s, want %s", resp.Message, tt.wantMsg)
+			}
+			if got := rec.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %s, want %s", got, tt.wantLanguage)
+			}
+		})
+	}
+}
+
+// TestHandler_LocalizeSharedCode 测试共用Code的错误码按消息键翻译，不会被替换为同Code其它错误码的翻译
+func TestHandler_LocalizeSharedCode(t *testing.T) {
+	custom := NewCatalog("en").
+		Add("en", ErrConflict.Code, ErrConflictMessage).
+		Add("zh", ErrConflict.Code, "资源冲突")
+
+	tests := []struct {
+		name    string
+		catalog *Catalog
+		err     Error
+		wantMsg string
+	}{
+		{name: "自定义目录没有消息键的翻译", catalog: custom, err: New(ErrUsernameExisted), wantMsg: ErrUsernameExistedMessage},
+		{name: "自定义目录翻译同Code的错误码", catalog: custom, err: New(ErrConflict), wantMsg: "资源冲突"},
+		{name: "默认目录按消息键翻译", catalog: DefaultCatalog, err: New(ErrUsernameExisted), wantMsg: "用户名已存在"},
+		{name: "默认目录翻译邮箱已存在", catalog: DefaultCatalog, err: New(ErrEmailExisted), wantMsg: "邮箱已存在"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept-Language", "zh-CN")
+			rec := httptest.NewRecorder()
+			NewHandler(nil, WithCatalog(tt.catalog)).WriteError(rec, req, tt.err)
+
+			resp := decodeErrorResponse(t, rec)
+			if resp.Code != ErrConflict.Code {
+				t.Errorf("Code = %s, want %s", resp.Code, ErrConflict.Code)
+			}
+			if resp.Message != tt.wantMsg {
+				t.Errorf("Message = %s, want %s", resp.Message, tt.wantMsg)
+			}
+		})
+	}
+}
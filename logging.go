@@ -0,0 +1,631 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// logConfig 结构化日志输出错误时的配置
+type logConfig struct {
+	// 脱敏器，作用于消息、详情、原始错误和元数据，nil表示不脱敏
+	redactor *Redactor
+	// 日志级别不低于该级别时输出堆栈
+	stackLevel zapcore.Level
+	// 原始错误链的最大深度
+	maxDepth int
+}
+
+type LogOption func(c *logConfig)
+
+// WithLogRedactor 设置脱敏器，默认为DefaultRedactor，传入nil时关闭脱敏
+func WithLogRedactor(r *Redactor) LogOption {
+	return func(c *logConfig) {
+		c.redactor = r
+	}
+}
+
+// WithLogStackLevel 设置输出堆栈的最低日志级别，默认为Error，
+// 传入zapcore.DebugLevel总是输出，传入大于FatalLevel的级别时不输出
+func WithLogStackLevel(level zapcore.Level) LogOption {
+	return func(c *logConfig) {
+		c.stackLevel = level
+	}
+}
+
+// WithLogMaxDepth 设置原始错误链的最大深度，默认10
+func WithLogMaxDepth(n int) LogOption {
+	return func(c *logConfig) {
+		c.maxDepth = max(1, n)
+	}
+}
+
+// globalLogConfig 当前生效的日志配置
+var globalLogConfig atomic.Pointer[logConfig]
+
+func init() {
+	globalLogConfig.Store(&logConfig{
+		redactor:   DefaultRedactor(),
+		stackLevel: zapcore.ErrorLevel,
+		maxDepth:   10,
+	})
+}
+
+// SetLogOptions 在当前配置的基础上修改结构化日志输出错误时的配置，返回恢复之前配置的函数
+func SetLogOptions(opts ...LogOption) (restore func()) {
+	prev := globalLogConfig.Load()
+	cfg := *prev
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	globalLogConfig.Store(&cfg)
+
+	return func() {
+		globalLogConfig.Store(prev)
+	}
+}
+
+// severityLevel 返回严重程度对应的日志级别，用于无法得知日志级别时决定是否输出堆栈
+func severityLevel(s Severity) zapcore.Level {
+	switch s {
+	case SeverityCritical, SeverityError:
+		return zapcore.ErrorLevel
+	case SeverityWarning:
+		return zapcore.WarnLevel
+	case SeverityDebug:
+		return zapcore.DebugLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// slogLevel 将slog的日志级别转换为zap的日志级别
+func slogLevel(level slog.Level) zapcore.Level {
+	switch {
+	case level >= slog.LevelError:
+		return zapcore.ErrorLevel
+	case level >= slog.LevelWarn:
+		return zapcore.WarnLevel
+	case level >= slog.LevelInfo:
+		return zapcore.InfoLevel
+	default:
+		return zapcore.DebugLevel
+	}
+}
+
+// logNode 原始错误链中一个错误的日志表示
+type logNode struct {
+	// 非Error类型的错误只有message和goType
+	isError   bool
+	code      string
+	errType   ErrType
+	status    int
+	severity  Severity
+	message   string
+	detail    string
+	goType    string
+	timestamp time.Time
+	stack     string
+	metadata  map[string]any
+	// 校验错误的字段错误
+	fields []FieldError
+	// 原始错误
+	cause *logNode
+	// 聚合错误的子错误，包括标准库errors.Join等Unwrap() []error的分支
+	children []*logNode
+}
+
+// buildLogNode 生成错误的日志表示，level为nil时按错误的严重程度决定是否输出堆栈
+func buildLogNode(err error, cfg *logConfig, level *zapcore.Level, depth int) *logNode {
+	if err == nil || depth > cfg.maxDepth {
+		return nil
+	}
+
+	// 包装了Error的标准错误按标准错误输出，再沿原始错误链展开
+	e, ok := err.(Error)
+	if !ok {
+		node := &logNode{
+			message: cfg.redactor.RedactString(err.Error()),
+			goType:  fmt.Sprintf("%T", err),
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			node.children = buildLogNodes(joined.Unwrap(), cfg, level, depth+1)
+			return node
+		}
+		node.cause = buildLogNode(errors.Unwrap(err), cfg, level, depth+1)
+		return node
+	}
+
+	node := &logNode{
+		isError:   true,
+		code:      e.Code(),
+		errType:   e.Type(),
+		status:    e.HttpStatus(),
+		severity:  SeverityOf(e),
+		message:   cfg.redactor.RedactString(e.Message()),
+		detail:    cfg.redactor.RedactString(e.Detail()),
+		timestamp: e.Timestamp(),
+		metadata:  cfg.redactor.RedactMetadata(e.Metadata()),
+	}
+
+	lvl := severityLevel(node.severity)
+	if level != nil {
+		lvl = *level
+	}
+	if lvl >= cfg.stackLevel {
+		node.stack = e.StackTrace()
+	}
+
+	if ve, ok := e.(*ValidationError); ok {
+		node.fields = make([]FieldError, 0, len(ve.Fields()))
+		for _, f := range ve.Fields() {
+			f.Message = cfg.redactor.RedactString(f.Message)
+			node.fields = append(node.fields, f)
+		}
+	}
+
+	if multi, ok := e.(*MultiError); ok {
+		children := make([]error, 0, multi.Len())
+		for _, child := range multi.Errors() {
+			children = append(children, child)
+		}
+		node.children = buildLogNodes(children, cfg, level, depth+1)
+		return node
+	}
+
+	node.cause = buildLogNode(e.Unwrap(), cfg, level, depth+1)
+	return node
+}
+
+// buildLogNodes 生成多个分支错误的日志表示
+func buildLogNodes(errs []error, cfg *logConfig, level *zapcore.Level, depth int) []*logNode {
+	var nodes []*logNode
+	for _, err := range errs {
+		if node := buildLogNode(err, cfg, level, depth); node != nil {
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler
+func (n *logNode) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if !n.isError {
+		enc.AddString("message", n.message)
+		enc.AddString("go_type", n.goType)
+		if len(n.children) > 0 {
+			if err := enc.AddArray("errors", logNodes(n.children)); err != nil {
+				return err
+			}
+		}
+		if n.cause != nil {
+			return enc.AddObject("cause", n.cause)
+		}
+		return nil
+	}
+
+	enc.AddString("code", n.code)
+	enc.AddString("type", n.errType.String())
+	enc.AddInt("http_status", n.status)
+	enc.AddString("severity", n.severity.String())
+	enc.AddString("message", n.message)
+	if n.detail != "" {
+		enc.AddString("detail", n.detail)
+	}
+	if !n.timestamp.IsZero() {
+		enc.AddTime("timestamp", n.timestamp)
+	}
+	if len(n.metadata) > 0 {
+		if err := enc.AddObject("metadata", logMetadata(n.metadata)); err != nil {
+			return err
+		}
+	}
+	if len(n.fields) > 0 {
+		if err := enc.AddArray("fields", logFields(n.fields)); err != nil {
+			return err
+		}
+	}
+	if n.stack != "" {
+		enc.AddString("stack", n.stack)
+	}
+	if len(n.children) > 0 {
+		if err := enc.AddArray("errors", logNodes(n.children)); err != nil {
+			return err
+		}
+	}
+	if n.cause != nil {
+		return enc.AddObject("cause", n.cause)
+	}
+
+	return nil
+}
+
+// slogValue 转换为slog的分组值
+func (n *logNode) slogValue() slog.Value {
+	if !n.isError {
+		attrs := []slog.Attr{slog.String("message", n.message), slog.String("go_type", n.goType)}
+		if len(n.children) > 0 {
+			attrs = append(attrs, slog.Any("errors", n.childrenValue()))
+		}
+		if n.cause != nil {
+			attrs = append(attrs, slog.Any("cause", n.cause.slogValue()))
+		}
+		return slog.GroupValue(attrs...)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("code", n.code),
+		slog.String("type", n.errType.String()),
+		slog.Int("http_status", n.status),
+		slog.String("severity", n.severity.String()),
+		slog.String("message", n.message),
+	}
+	if n.detail != "" {
+		attrs = append(attrs, slog.String("detail", n.detail))
+	}
+	if !n.timestamp.IsZero() {
+		attrs = append(attrs, slog.Time("timestamp", n.timestamp))
+	}
+	if len(n.metadata) > 0 {
+		metadata := make([]slog.Attr, 0, len(n.metadata))
+		for _, k := range slices.Sorted(maps.Keys(n.metadata)) {
+			metadata = append(metadata, slog.Any(k, n.metadata[k]))
+		}
+		attrs = append(attrs, slog.Any("metadata", slog.GroupValue(metadata...)))
+	}
+	if len(n.fields) > 0 {
+		fields := make([]slog.Attr, 0, len(n.fields))
+		for i, f := range n.fields {
+			fields = append(fields, slog.Any(fmt.Sprint(i), logField(f).slogValue()))
+		}
+		attrs = append(attrs, slog.Any("fields", slog.GroupValue(fields...)))
+	}
+	if n.stack != "" {
+		attrs = append(attrs, slog.String("stack", n.stack))
+	}
+	if len(n.children) > 0 {
+		attrs = append(attrs, slog.Any("errors", n.childrenValue()))
+	}
+	if n.cause != nil {
+		attrs = append(attrs, slog.Any("cause", n.cause.slogValue()))
+	}
+
+	return slog.GroupValue(attrs...)
+}
+
+// childrenValue 子错误的slog分组值，按下标作为键
+func (n *logNode) childrenValue() slog.Value {
+	children := make([]slog.Attr, 0, len(n.children))
+	for i, child := range n.children {
+		children = append(children, slog.Any(fmt.Sprint(i), child.slogValue()))
+	}
+
+	return slog.GroupValue(children...)
+}
+
+// logNodes 聚合错误子错误的数组
+type logNodes []*logNode
+
+func (ns logNodes) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, n := range ns {
+		if err := enc.AppendObject(n); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// logField 校验错误的单个字段错误
+type logField FieldError
+
+func (f logField) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("field", f.Field)
+	enc.AddString("rule", f.Rule)
+	if f.Param != "" {
+		enc.AddString("param", f.Param)
+	}
+	enc.AddString("message", f.Message)
+	return nil
+}
+
+func (f logField) slogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("field", f.Field), slog.String("rule", f.Rule)}
+	if f.Param != "" {
+		attrs = append(attrs, slog.String("param", f.Param))
+	}
+	attrs = append(attrs, slog.String("message", f.Message))
+
+	return slog.GroupValue(attrs...)
+}
+
+// logFields 校验错误的字段错误数组
+type logFields []FieldError
+
+func (fs logFields) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, f := range fs {
+		if err := enc.AppendObject(logField(f)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// logMetadata 按键排序输出的元数据
+type logMetadata map[string]any
+
+func (m logMetadata) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		if err := enc.AddReflected(k, m[k]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// logObject 日志中的错误，level在写入时由NewZapCore或NewSlogHandler根据日志级别填充
+type logObject struct {
+	err   error
+	level *zapcore.Level
+}
+
+func (o logObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	node := buildLogNode(o.err, globalLogConfig.Load(), o.level, 1)
+	if node == nil {
+		return nil
+	}
+
+	return node.MarshalLogObject(enc)
+}
+
+func (o logObject) LogValue() slog.Value {
+	node := buildLogNode(o.err, globalLogConfig.Load(), o.level, 1)
+	if node == nil {
+		return slog.Value{}
+	}
+
+	return node.slogValue()
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler，输出错误码、类型、状态码、严重程度、
+// 脱敏后的消息和元数据以及完整的原始错误链，zap.Any和zap.Object会使用该实现
+// 堆栈是否输出由SetLogOptions配置的级别决定，通过NewZapCore包装的Core按日志级别判断，否则按错误的严重程度判断
+func (e *ErrorImpl) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return logObject{err: e}.MarshalLogObject(enc)
+}
+
+// LogValue 实现slog.LogValuer，输出的内容与MarshalLogObject一致
+func (e *ErrorImpl) LogValue() slog.Value {
+	return logObject{err: e}.LogValue()
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler，在ErrorImpl的基础上输出字段错误，
+// 需要显式实现，否则嵌入的ErrorImpl方法只能看到内部的ErrorImpl
+func (e *ValidationError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return logObject{err: e}.MarshalLogObject(enc)
+}
+
+// LogValue 实现slog.LogValuer，输出的内容与MarshalLogObject一致
+func (e *ValidationError) LogValue() slog.Value {
+	return logObject{err: e}.LogValue()
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler，以PanicError本身展开原始错误链
+func (e *PanicError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return logObject{err: e}.MarshalLogObject(enc)
+}
+
+// LogValue 实现slog.LogValuer，输出的内容与MarshalLogObject一致
+func (e *PanicError) LogValue() slog.Value {
+	return logObject{err: e}.LogValue()
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler，输出聚合错误自身的信息并在errors中展开所有子错误
+func (m *MultiError) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return logObject{err: m}.MarshalLogObject(enc)
+}
+
+// LogValue 实现slog.LogValuer，输出的内容与MarshalLogObject一致
+func (m *MultiError) LogValue() slog.Value {
+	return logObject{err: m}.LogValue()
+}
+
+// ZapError 返回键为error的结构化错误字段，任意error都可以使用，err为nil时返回zap.Skip
+func ZapError(err error) zap.Field {
+	return NamedZapError("error", err)
+}
+
+// NamedZapError 返回指定键的结构化错误字段，err为nil时返回zap.Skip
+func NamedZapError(key string, err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+
+	return zap.Object(key, logObject{err: err})
+}
+
+// SlogError 返回键为error的结构化错误属性，任意error都可以使用
+func SlogError(err error) slog.Attr {
+	return slog.Any("error", logObject{err: err})
+}
+
+// NewZapCore 包装zapcore.Core，写入时按日志级别决定结构化错误字段是否输出堆栈
+func NewZapCore(core zapcore.Core) zapcore.Core {
+	return &errorCore{Core: core}
+}
+
+// errorCore 按日志级别决定是否输出堆栈的zapcore.Core
+type errorCore struct {
+	zapcore.Core
+}
+
+func (c *errorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &errorCore{Core: c.Core.With(fields)}
+}
+
+func (c *errorCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+
+	return ce
+}
+
+func (c *errorCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	var replaced []zapcore.Field
+	for i, f := range fields {
+		if f.Type != zapcore.ObjectMarshalerType {
+			continue
+		}
+
+		var err error
+		switch v := f.Interface.(type) {
+		case logObject:
+			err = v.err
+		case Error:
+			// ErrorImpl以及嵌入它的包装类型
+			err = v
+		default:
+			continue
+		}
+
+		if replaced == nil {
+			replaced = slices.Clone(fields)
+		}
+		level := entry.Level
+		replaced[i].Interface = logObject{err: err, level: &level}
+	}
+
+	if replaced != nil {
+		fields = replaced
+	}
+
+	return c.Core.Write(entry, fields)
+}
+
+// NewSlogHandler 包装slog.Handler，处理记录时按日志级别决定结构化错误属性是否输出堆栈，
+// 分组中和通过With添加的错误同样生效
+func NewSlogHandler(h slog.Handler) slog.Handler {
+	return &errorHandler{Handler: h}
+}
+
+// errorHandler 按日志级别决定是否输出堆栈的slog.Handler
+// 通过With添加的属性包含错误时，添加时还不知道记录的级别，因此先不交给内层Handler，
+// 处理记录时按记录的级别展开后再应用，其后的WithAttrs和WithGroup也按顺序延后，保持分组的嵌套关系
+type errorHandler struct {
+	slog.Handler
+	// 延后应用的WithAttrs和WithGroup
+	pending []slogHandlerOp
+}
+
+// slogHandlerOp 延后应用的WithAttrs或WithGroup，group为空时表示WithAttrs
+type slogHandlerOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+func (h *errorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(h.pending) == 0 && !slices.ContainsFunc(attrs, hasSlogError) {
+		return &errorHandler{Handler: h.Handler.WithAttrs(attrs)}
+	}
+
+	return &errorHandler{Handler: h.Handler, pending: append(slices.Clip(h.pending), slogHandlerOp{attrs: attrs})}
+}
+
+func (h *errorHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	if len(h.pending) == 0 {
+		return &errorHandler{Handler: h.Handler.WithGroup(name)}
+	}
+
+	return &errorHandler{Handler: h.Handler, pending: append(slices.Clip(h.pending), slogHandlerOp{group: name})}
+}
+
+func (h *errorHandler) Handle(ctx context.Context, record slog.Record) error {
+	level := slogLevel(record.Level)
+	handler := h.Handler
+	for _, op := range h.pending {
+		if op.group != "" {
+			handler = handler.WithGroup(op.group)
+			continue
+		}
+		handler = handler.WithAttrs(resolveSlogAttrs(op.attrs, &level))
+	}
+
+	replaced := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	record.Attrs(func(a slog.Attr) bool {
+		replaced.AddAttrs(resolveSlogAttr(a, &level))
+		return true
+	})
+
+	return handler.Handle(ctx, replaced)
+}
+
+// resolveSlogAttr 将属性中的错误替换为按日志级别展开的值，分组中的错误同样会被替换
+func resolveSlogAttr(a slog.Attr, level *zapcore.Level) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindLogValuer:
+		switch v := a.Value.Any().(type) {
+		case logObject:
+			a.Value = logObject{err: v.err, level: level}.LogValue()
+		case Error:
+			a.Value = logObject{err: v, level: level}.LogValue()
+		}
+	case slog.KindGroup:
+		a.Value = slog.GroupValue(resolveSlogAttrs(a.Value.Group(), level)...)
+	}
+
+	return a
+}
+
+// resolveSlogAttrs 替换多个属性中的错误
+func resolveSlogAttrs(attrs []slog.Attr, level *zapcore.Level) []slog.Attr {
+	resolved := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		resolved[i] = resolveSlogAttr(a, level)
+	}
+
+	return resolved
+}
+
+// hasSlogError 判断属性或分组中是否包含需要按日志级别展开的错误
+func hasSlogError(a slog.Attr) bool {
+	switch a.Value.Kind() {
+	case slog.KindLogValuer:
+		switch a.Value.Any().(type) {
+		case logObject, Error:
+			return true
+		}
+	case slog.KindGroup:
+		return slices.ContainsFunc(a.Value.Group(), hasSlogError)
+	}
+
+	return false
+}
@@ -0,0 +1,261 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newJSONLogger 返回输出JSON到buf的logger，wrap为true时使用NewZapCore包装
+func newJSONLogger(buf *bytes.Buffer, wrap bool) *zap.Logger {
+	cfg := zap.NewProductionEncoderConfig()
+	cfg.TimeKey = ""
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(cfg), zapcore.AddSync(buf), zapcore.DebugLevel)
+	if wrap {
+		core = NewZapCore(core)
+	}
+
+	return zap.New(core)
+}
+
+// decodeLogLine 解析最后一行JSON日志
+func decodeLogLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	t.Helper()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var line map[string]any
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &line); err != nil {
+		t.Fatalf("解析日志失败: %v\n%s", err, buf.String())
+	}
+	buf.Reset()
+
+	return line
+}
+
+// newLogTestError 返回标准错误包装的Error，Error的原始错误为io.EOF
+func newLogTestError() error {
+	err := Wrap(io.EOF, ErrInternal).
+		WithMetadata("password", "hunter2").
+		WithMetadata("user_id", 42)
+	return fmt.Errorf("load user: %w", err)
+}
+
+// TestZapError 测试zap字段输出完整的原始错误链，脱敏元数据并按日志级别输出堆栈
+func TestZapError(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONLogger(&buf, true)
+
+	l.Error("failed", ZapError(newLogTestError()))
+	line := decodeLogLine(t, &buf)
+
+	outer := line["error"].(map[string]any)
+	if outer["go_type"] != "*fmt.wrapError" || outer["message"] == "" {
+		t.Errorf("outer = %v", outer)
+	}
+	e := outer["cause"].(map[string]any)
+	if e["code"] != ErrInternal.Code || e["type"] != "INTERNAL" || e["http_status"] != float64(500) || e["severity"] != "error" {
+		t.Errorf("error = %v", e)
+	}
+	metadata := e["metadata"].(map[string]any)
+	if metadata["password"] == "hunter2" || metadata["user_id"] != float64(42) {
+		t.Errorf("metadata = %v", metadata)
+	}
+	if stack, _ := e["stack"].(string); !strings.Contains(stack, "logging_test.go") {
+		t.Errorf("Error级别应该输出堆栈: %v", e["stack"])
+	}
+	if cause := e["cause"].(map[string]any); cause["message"] != "EOF" {
+		t.Errorf("cause = %v", cause)
+	}
+
+	l.Warn("failed", ZapError(newLogTestError()))
+	e = decodeLogLine(t, &buf)["error"].(map[string]any)["cause"].(map[string]any)
+	if _, ok := e["stack"]; ok {
+		t.Error("Warn级别不应该输出堆栈")
+	}
+
+	l.Info("ok", ZapError(nil))
+	if _, ok := decodeLogLine(t, &buf)["error"]; ok {
+		t.Error("nil不应该输出字段")
+	}
+}
+
+// TestErrorImpl_MarshalLogObject 测试zap.Any使用ObjectMarshaler，未包装Core时按严重程度输出堆栈
+func TestErrorImpl_MarshalLogObject(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONLogger(&buf, false)
+
+	l.Info("x", zap.Any("err", New(ErrValidation)))
+	e := decodeLogLine(t, &buf)["err"].(map[string]any)
+	if e["code"] != ErrValidation.Code {
+		t.Errorf("err = %v", e)
+	}
+	if _, ok := e["stack"]; ok {
+		t.Error("warning严重程度的错误不应该输出堆栈")
+	}
+
+	l.Info("x", zap.Any("err", New(ErrInternal)))
+	if e := decodeLogLine(t, &buf)["err"].(map[string]any); e["stack"] == nil {
+		t.Error("error严重程度的错误应该输出堆栈")
+	}
+
+	multi := Join(New(ErrNotFound), New(ErrTimeout))
+	l.Info("x", ZapError(multi))
+	if e := decodeLogLine(t, &buf)["error"].(map[string]any); len(e["errors"].([]any)) != 2 {
+		t.Errorf("errors = %v", e["errors"])
+	}
+}
+
+// TestSetLogOptions 测试关闭脱敏、总是输出堆栈和限制原始错误链深度
+func TestSetLogOptions(t *testing.T) {
+	defer SetLogOptions(WithLogRedactor(nil), WithLogStackLevel(zapcore.DebugLevel), WithLogMaxDepth(2))()
+
+	var buf bytes.Buffer
+	newJSONLogger(&buf, true).Debug("x", ZapError(newLogTestError()))
+
+	e := decodeLogLine(t, &buf)["error"].(map[string]any)["cause"].(map[string]any)
+	if e["metadata"].(map[string]any)["password"] != "hunter2" {
+		t.Error("关闭脱敏后应该输出原值")
+	}
+	if e["stack"] == nil {
+		t.Error("Debug级别应该输出堆栈")
+	}
+	if _, ok := e["cause"]; ok {
+		t.Error("超过最大深度的原始错误不应该输出")
+	}
+}
+
+// TestSlogError 测试slog的LogValuer和按日志级别输出堆栈
+func TestSlogError(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	l.Error("failed", SlogError(newLogTestError()))
+	e := decodeLogLine(t, &buf)["error"].(map[string]any)["cause"].(map[string]any)
+	if e["code"] != ErrInternal.Code || e["stack"] == nil {
+		t.Errorf("error = %v", e)
+	}
+	if e["metadata"].(map[string]any)["password"] == "hunter2" {
+		t.Error("元数据应该脱敏")
+	}
+
+	l.Warn("failed", "err", New(ErrInternal))
+	e = decodeLogLine(t, &buf)["err"].(map[string]any)
+	if e["code"] != ErrInternal.Code || e["stack"] != nil {
+		t.Errorf("Warn级别不应该输出堆栈: %v", e)
+	}
+
+	// 未包装的Handler直接使用LogValue
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", "err", New(ErrNotFound))
+	if e := decodeLogLine(t, &buf)["err"].(map[string]any); e["code"] != ErrNotFound.Code {
+		t.Errorf("err = %v", e)
+	}
+}
+
+// TestLogFieldsAndJoin 测试校验错误输出字段错误、PanicError保留外层类型以及展开Unwrap() []error的分支
+func TestLogFieldsAndJoin(t *testing.T) {
+	var buf bytes.Buffer
+	l := newJSONLogger(&buf, false)
+
+	ve := NewValidationError(FieldError{Field: "email", Rule: "email", Message: "alice@example.com is invalid"})
+	l.Info("x", zap.Any("err", ve))
+	fields, _ := decodeLogLine(t, &buf)["err"].(map[string]any)["fields"].([]any)
+	if len(fields) != 1 {
+		t.Fatalf("fields = %v", fields)
+	}
+	if f := fields[0].(map[string]any); f["field"] != "email" || f["rule"] != "email" || strings.Contains(f["message"].(string), "alice") {
+		t.Errorf("field = %v", f)
+	}
+
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", "err", ve)
+	if e := decodeLogLine(t, &buf)["err"].(map[string]any); e["fields"].(map[string]any)["0"].(map[string]any)["field"] != "email" {
+		t.Errorf("slog fields = %v", e["fields"])
+	}
+
+	pe := NewPanicError(io.ErrUnexpectedEOF, nil)
+	l.Info("x", zap.Any("err", pe))
+	if e := decodeLogLine(t, &buf)["err"].(map[string]any); e["code"] != ErrPanicRecovered.Code || e["cause"].(map[string]any)["message"] != io.ErrUnexpectedEOF.Error() {
+		t.Errorf("panic = %v", e)
+	}
+
+	joined := errors.Join(New(ErrNotFound), io.EOF)
+	l.Info("x", ZapError(NewBuilder().WithCode(ErrInternal).WithCause(joined).Build()))
+	cause := decodeLogLine(t, &buf)["error"].(map[string]any)["cause"].(map[string]any)
+	children, _ := cause["errors"].([]any)
+	if len(children) != 2 || children[0].(map[string]any)["code"] != ErrNotFound.Code || children[1].(map[string]any)["message"] != "EOF" {
+		t.Errorf("cause = %v", cause)
+	}
+
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", SlogError(joined))
+	if e := decodeLogLine(t, &buf)["error"].(map[string]any); e["errors"].(map[string]any)["1"].(map[string]any)["message"] != "EOF" {
+		t.Errorf("slog errors = %v", e)
+	}
+}
+
+// TestMultiError_LogObject 测试zap.Any和slog直接展开聚合错误的所有子错误
+func TestMultiError_LogObject(t *testing.T) {
+	var buf bytes.Buffer
+	multi := Join(New(ErrNotFound), Newf(ErrConflict, "alice@example.com exists"))
+
+	newJSONLogger(&buf, false).Info("x", zap.Any("err", multi))
+	children, _ := decodeLogLine(t, &buf)["err"].(map[string]any)["errors"].([]any)
+	if len(children) != 2 || children[1].(map[string]any)["code"] != ErrConflict.Code {
+		t.Fatalf("errors = %v", children)
+	}
+	if strings.Contains(children[1].(map[string]any)["message"].(string), "alice") {
+		t.Errorf("子错误的消息应该脱敏: %v", children[1])
+	}
+
+	slog.New(slog.NewJSONHandler(&buf, nil)).Info("x", "err", multi)
+	if e := decodeLogLine(t, &buf)["err"].(map[string]any); e["errors"].(map[string]any)["0"].(map[string]any)["code"] != ErrNotFound.Code {
+		t.Errorf("slog errors = %v", e)
+	}
+}
+
+// TestSlogHandler_GroupAndWith 测试分组中和通过With添加的错误同样按记录的级别输出堆栈
+func TestSlogHandler_GroupAndWith(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+
+	l.Warn("x", slog.Group("req", "err", New(ErrInternal)))
+	e := decodeLogLine(t, &buf)["req"].(map[string]any)["err"].(map[string]any)
+	if e["code"] != ErrInternal.Code || e["stack"] != nil {
+		t.Errorf("分组中的错误应该按Warn级别不输出堆栈: %v", e)
+	}
+
+	with := l.With("err", New(ErrValidation)).WithGroup("req").With("id", 1)
+	with.Error("x", "path", "/users")
+	line := decodeLogLine(t, &buf)
+	if e := line["err"].(map[string]any); e["code"] != ErrValidation.Code || e["stack"] == nil {
+		t.Errorf("With添加的错误应该按Error级别输出堆栈: %v", e)
+	}
+	if req := line["req"].(map[string]any); req["id"] != float64(1) || req["path"] != "/users" {
+		t.Errorf("分组关系不正确: %v", line)
+	}
+
+	with.Warn("x")
+	if e := decodeLogLine(t, &buf)["err"].(map[string]any); e["stack"] != nil {
+		t.Errorf("With添加的错误应该按Warn级别不输出堆栈: %v", e)
+	}
+}
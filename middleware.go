@@ -1,283 +1,751 @@
-package errors
-
-//
-//import (
-
-//)
-//
-//type ErrorResponse struct {
-//	// 是否处理成功
-//	Success bool `json:"success"`
-//	// 错误码
-//	ErrCode *ErrCode `json:"errCode"`
-//	// 错误详情
-//	Message string `json:"message"`
-//	// 请求ID
-//	RequestID string `json:"requestId,omitempty"`
-//	// 纬度ID
-//	SpanID string `json:"spanId,omitempty"`
-//	// 跟踪ID
-//	TraceID string `json:"traceID,omitempty"`
-//	// 详情信息
-//	Details map[string]any `json:"details"`
-//	// 时间戳
-//	Timestamp string `json:"timestamp"`
-//}
-//
-//type Handler struct {
-//	// 日志适配器
-//	l logger.Logger
-//	// 是否显示错误详情
-//	showDetails bool
-//	// 是有隐藏内部
-//	hideInternal bool
-//	// 是否启用监控
-//	enableMonitor bool
-//	// 环境
-//	environment string
-//}
-//
-//func NewHandler(l logger.Logger, opts ...HandlerOption) *Handler {
-//	h := &Handler{
-//		l: l,
-//	}
-//
-//	for _, opt := range opts {
-//		opt(h)
-//	}
-//
-//	return h
-//}
-//
-//// RecoveryMiddleware 适配Gin框架的错误恢复中间件
-//func (h *Handler) RecoveryMiddleware() gin.HandlerFunc {
-//	return func(c *gin.Context) {
-//		defer func() {
-//			r := recover()
-//			if r == nil {
-//				return
-//			}
-//
-//			panicError := h.createPanicError(r, debug.Stack())
-//			h.handleError(c, panicError)
-//			h.l.Error("panic recovered",
-//				logger.AnyField("panic", r),
-//				logger.StringField("stack", string(debug.Stack())),
-//				logger.StringField("path", c.Request.URL.Path))
-//
-//			c.Abort()
-//		}()
-//
-//		c.Next()
-//	}
-//}
-//
-//// HandleError 处理错误，包括日志的记录、监控的记录和错误响应的构建
-//func (h *Handler) handleError(c *gin.Context, err Error) {
-//	h.recordError(c, err)
-//	h.buildErrorResponse(c, err)
-//}
-//
-//// recordError 记录错误到日志和监控系统
-//func (h *Handler) recordError(c *gin.Context, err Error) {
-//	if h.enableMonitor {
-//		// TODO 记录错误到监控系统
-//	}
-//
-//	// 记录机构化日志
-//	fields := []logger.Field{
-//		logger.StringField("code", err.Code()),
-//		logger.StringField("type", err.Type().String()),
-//		logger.StringField("path", c.Request.URL.Path),
-//		logger.StringField("method", c.Request.Method),
-//		logger.IntField("http_status", err.HttpStatus()),
-//		logger.TimeField("timestamp", err.Timestamp()),
-//	}
-//
-//	// 添加其它的信息
-//	// 记录SpanID信息，部分会叫做RequestID
-//	requestID := c.GetString("request_id")
-//	if requestID != "" {
-//		fields = append(fields, logger.StringField("request_id", requestID))
-//	}
-//
-//	spanID := c.GetString("span_id")
-//	if spanID != "" {
-//		fields = append(fields, logger.StringField("span_id", spanID))
-//	}
-//
-//	// 记录客户端IP
-//	if client := c.ClientIP(); client != "" {
-//		fields = append(fields, logger.StringField("client_ip", client))
-//	}
-//
-//	// 记录其他的元数据信息
-//	if metadata := err.Metadata(); len(metadata) > 0 {
-//		fields = append(fields, logger.AnyField("metadata", metadata))
-//	}
-//
-//	// 根据错误类型来决定日志记录的级别
-//	switch err.Type() {
-//	case ErrTypeInternal, ErrTypeTimeout, ErrTypeExternal:
-//		h.l.Error("internal error", fields...)
-//	case ErrTypeBusiness, ErrTypeValidation:
-//		h.l.Warn("business error", fields...)
-//	default:
-//		h.l.Info("unknown error", fields...)
-//	}
-//}
-//
-//// buildErrorResponse 构建错误响应
-//func (h *Handler) buildErrorResponse(c *gin.Context, err Error) {
-//	errResponse := ErrorResponse{
-//		Success:   false,
-//		Message:   err.Message(),
-//		Timestamp: err.Timestamp().Format(time.RFC3339),
-//	}
-//
-//	requestID := c.GetString("request_id")
-//	if requestID != "" {
-//		errResponse.RequestID = requestID
-//	}
-//
-//	spanID := c.GetString("span_id")
-//	if spanID != "" {
-//		errResponse.SpanID = spanID
-//	}
-//
-//	traceID := c.GetString("trace_id")
-//	if traceID != "" {
-//		errResponse.TraceID = traceID
-//	}
-//
-//	status := err.HttpStatus()
-//	if status == 0 {
-//		status = http.StatusInternalServerError
-//	}
-//
-//	c.JSON(status, errResponse)
-//}
-//
-//// createPanicError 创建panic错误
-//func (h *Handler) createPanicError(panicValue any, stack []byte) Error {
-//	var panicMessage string
-//	switch v := panicValue.(type) {
-//	case error:
-//		panicMessage = v.Error()
-//	case string:
-//		panicMessage = v
-//	default:
-//		panicMessage = fmt.Sprintf("%v", v)
-//	}
-//
-//	return NewBuilder().
-//		WithCode(&ErrCode{
-//			Code:       "PANIC_RECOVERED",
-//			Message:    "Service encountered a panic and recovered",
-//			HttpStatus: http.StatusInternalServerError,
-//			Type:       ErrTypeInternal,
-//		}).
-//		WithMessage(fmt.Sprintf("panic recovered: %s", panicMessage)).
-//		WithMetadata("panic_value", panicValue).
-//		WithMetadata("panic_type", fmt.Sprintf("%T", panicValue)).
-//		WithMetadata("stack_trace", string(stack)).
-//		Build()
-//}
-//
-//// createPanicErrorSimple 创建简化版的panic错误
-//func (h *Handler) createPanicErrorSimple(panicValue any, stack []byte) Error {
-//	panicMsg := fmt.Sprintf("%v", panicValue)
-//	return Newf(ErrInternal, "panic recovered: %s", panicMsg).
-//		WithMetadata("panic_value", panicValue).
-//		WithMetadata("panic_type", fmt.Sprintf("%T", panicValue)).
-//		WithMetadata("stack_trace", string(stack))
-//}
-//
-////// LoggingMiddleware 适配Gin框架中记录日志的中间件
-////func (h *Handler) LoggingMiddleware() gin.HandlerFunc {
-////	return func(c *gin.Context) {
-////		start := time.Now()
-////		h.l.Debug("start request",
-////			logger.StringField("method", c.Request.Method),
-////			logger.StringField("path", c.Request.URL.Path),
-////			logger.StringField("client_ip", c.ClientIP()),
-////			logger.StringField("user_agent", c.Request.UserAgent()),
-////			logger.StringField("request_id", c.GetString("request_id")),
-////			logger.TimeField("timestamp", start))
-////
-////		c.Next()
-////		latency := time.Since(start)
-////		status := c.Writer.Status()
-////		fields := []logger.Field{
-////			logger.StringField("method", c.Request.Method),
-////			logger.StringField("path", c.Request.URL.Path),
-////			logger.StringField("client_ip", c.ClientIP()),
-////			logger.StringField("user_agent", c.Request.UserAgent()),
-////			logger.StringField("request_id", c.GetString("request_id")),
-////			logger.DurationField("latency", latency),
-////			logger.TimeField("timestamp", start),
-////			logger.IntField("status", status),
-////		}
-////
-////		// 响应的大小
-////		if size := c.Writer.Size(); size > 0 {
-////			fields = append(fields, logger.IntField("size", size))
-////		}
-////
-////		// 根据状态码来决定使用什么级别
-////		switch {
-////		case status >= 500:
-////			// 服务器内部错误
-////			h.l.Error("server error", fields...)
-////		case status >= 400:
-////			// 客户端错误
-////			h.l.Warn("client error", fields...)
-////		default:
-////			// 正常请求
-////			h.l.Info("request completed", fields...)
-////		}
-////	}
-////}
-//
-//func (h *Handler) ErrorMiddleware() gin.HandlerFunc {
-//	return func(c *gin.Context) {
-//		// 先处理请求
-//		c.Next()
-//
-//		// 处理错误
-//		if c.Errors != nil && len(c.Errors) > 0 {
-//			for _, err := range c.Errors {
-//				h.handleError(c, err.Err.(Error))
-//			}
-//		}
-//	}
-//}
+// Copyright 2025 TimeWtr
 //
-//// TimeoutMiddleware 适配Gin框架的请求超时处理中间件
-//func (h *Handler) TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
-//	return func(c *gin.Context) {
-//		ctx, cancel := context.WithTimeout(context.Background(), timeout)
-//		defer cancel()
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
 //
-//		c.Request.WithContext(ctx)
-//		done := make(chan struct{})
-//		go func() {
-//			defer close(done)
-//			c.Next()
-//		}()
+// http://www.apache.org/licenses/LICENSE-2.0
 //
-//		select {
-//		case <-done:
-//		// 正常处理完整，未超时
-//		case <-ctx.Done():
-//			// 请求处理超时了
-//			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-//				timeoutErr := Newf(ErrTimeout, "request timed out").
-//					WithMetadata("method", c.Request.Method).
-//					WithMetadata("path", c.Request.URL.Path)
-//				h.handleError(c, timeoutErr)
-//				c.Abort()
-//			}
-//		}
-//	}
-//}
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// HeaderRequestID 请求ID的请求头，Gin上下文中没有request_id时使用
+	HeaderRequestID = "X-Request-ID"
+	// HeaderSpanID 纬度ID的请求头
+	HeaderSpanID = "X-Span-ID"
+	// HeaderTraceID 跟踪ID的请求头
+	HeaderTraceID = "X-Trace-ID"
+)
+
+// ErrorResponse 统一的错误响应结构，Gin和net/http共用
+type ErrorResponse struct {
+	// 是否处理成功
+	Success bool `json:"success"`
+	// 错误码
+	Code string `json:"code"`
+	// 错误类型
+	Type ErrType `json:"type"`
+	// 错误信息
+	Message string `json:"message"`
+	// 面向开发者的内部详情，仅在开启详情且允许暴露时返回
+	Detail string `json:"detail,omitempty"`
+	// 请求ID
+	RequestID string `json:"requestId,omitempty"`
+	// 纬度ID
+	SpanID string `json:"spanId,omitempty"`
+	// 跟踪ID
+	TraceID string `json:"traceId,omitempty"`
+	// 详情信息
+	Details map[string]any `json:"details,omitempty"`
+	// 字段校验错误
+	Fields []FieldError `json:"fields,omitempty"`
+	// 聚合错误的子错误
+	Errors []ErrorItem `json:"errors,omitempty"`
+	// 时间戳
+	Timestamp string `json:"timestamp"`
+}
+
+// ErrorItem 聚合错误中单个子错误的响应结构
+type ErrorItem struct {
+	// 错误码
+	Code string `json:"code"`
+	// 错误类型
+	Type ErrType `json:"type"`
+	// 错误信息
+	Message string `json:"message"`
+	// 详情信息
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type Handler struct {
+	// 日志
+	l *zap.Logger
+	// 是否显示错误详情
+	showDetails bool
+	// 是否隐藏内部错误
+	hideInternal bool
+	// 环境
+	environment string
+	// 脱敏器，作用于响应和日志中的错误信息、原始错误和元数据
+	redactor *Redactor
+	// 翻译目录，根据Accept-Language返回本地化的错误信息
+	catalog *Catalog
+	// RATE_LIMIT和TIMEOUT错误没有重试提示时默认的重试等待时间
+	defaultRetryAfter time.Duration
+	// 错误监控，记录的错误会计入监控统计
+	monitor *Monitor
+	// 最近错误缓冲区，处理的错误会连同请求信息一起记录
+	recent *RecentErrors
+	// 日志采样器，高频错误超过采样阈值后不再逐条记录日志
+	sampler *Sampler
+	// SLO统计，Gin中间件和net/http的Recover按路由记录每个请求
+	slo *SLOTracker
+	// net/http请求的路由模板，默认为ServeMux匹配的Request.Pattern
+	httpRoute func(r *http.Request) string
+	// 可信代理的网段，只有直连地址属于可信代理时才使用X-Forwarded-For
+	trustedProxies []netip.Prefix
+}
+
+func NewHandler(l *zap.Logger, opts ...HandlerOption) *Handler {
+	if l == nil {
+		l = zap.NewNop()
+	}
+
+	h := &Handler{
+		l:           l,
+		environment: "dev",
+		redactor:    DefaultRedactor(),
+		catalog:     DefaultCatalog,
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// requestInfo 记录和渲染错误时需要的请求信息，屏蔽Gin和net/http之间的差异
+type requestInfo struct {
+	method    string
+	path      string
+	clientIP  string
+	requestID string
+	spanID    string
+	traceID   string
+	// 客户端期望的语言，按优先级排序
+	locales []string
+}
+
+// httpRequestInfo 从net/http请求中提取请求信息
+func (h *Handler) httpRequestInfo(r *http.Request) requestInfo {
+	return requestInfo{
+		method:    r.Method,
+		path:      r.URL.Path,
+		clientIP:  h.clientIP(r),
+		requestID: r.Header.Get(HeaderRequestID),
+		spanID:    r.Header.Get(HeaderSpanID),
+		traceID:   r.Header.Get(HeaderTraceID),
+		locales:   ParseAcceptLanguage(r.Header.Get("Accept-Language")),
+	}
+}
+
+// clientIP 返回客户端地址，直连地址属于可信代理时，从右向左跳过X-Forwarded-For中的可信代理，
+// 取第一个不可信的地址，未设置可信代理时X-Forwarded-For可以被客户端伪造，因此直接使用直连地址
+func (h *Handler) clientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !h.isTrustedProxy(remote) {
+		return remote
+	}
+
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			break
+		}
+		if !h.isTrustedProxy(hop) {
+			return hop
+		}
+		remote = hop
+	}
+
+	return remote
+}
+
+// isTrustedProxy 地址是否属于可信代理
+func (h *Handler) isTrustedProxy(ip string) bool {
+	if len(h.trustedProxies) == 0 {
+		return false
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range h.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ginRequestInfo 从Gin上下文中提取请求信息，客户端地址使用Gin的可信代理配置，上下文中没有的字段回退到请求头
+func (h *Handler) ginRequestInfo(c *gin.Context) requestInfo {
+	info := h.httpRequestInfo(c.Request)
+	info.clientIP = c.ClientIP()
+	if requestID := c.GetString("request_id"); requestID != "" {
+		info.requestID = requestID
+	}
+	if spanID := c.GetString("span_id"); spanID != "" {
+		info.spanID = spanID
+	}
+	if traceID := c.GetString("trace_id"); traceID != "" {
+		info.traceID = traceID
+	}
+
+	return info
+}
+
+// asError 将任意error转换为Error，非Error类型的错误使用DefaultClassifier分类包装，
+// 因此标准库错误的响应状态码由分类规则决定，例如sql.ErrNoRows为404、context.Canceled为499，
+// 没有规则命中时才按INTERNAL返回500
+func asError(err error) Error {
+	if err == nil {
+		return nil
+	}
+
+	var customErr Error
+	if errors.As(err, &customErr) {
+		return customErr
+	}
+
+	return DefaultClassifier.FastWrap(err)
+}
+
+// RecoveryMiddleware 适配Gin框架的错误恢复中间件，panic前已经写入响应时只记录错误，不再渲染错误响应，
+// 设置了SLO统计且没有使用ErrorMiddleware时，由该中间件按路由记录每个请求
+func (h *Handler) RecoveryMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			err := NewPanicError(r, debug.Stack())
+			info := h.ginRequestInfo(c)
+			h.logPanic(info, err)
+			// panic前已经写入响应时只记录错误，避免在已写出的响应后追加错误响应
+			if c.Writer.Written() {
+				h.recordError(info, err)
+			} else {
+				h.handleError(c.Writer, info, err)
+			}
+			h.recordSLO(c, err)
+			c.Abort()
+		}()
+
+		c.Next()
+
+		// 外层的ErrorMiddleware还没有渲染错误响应，由它记录
+		if _, ok := c.Get(errorMiddlewareKey); ok {
+			return
+		}
+
+		var err Error
+		if last := c.Errors.Last(); last != nil {
+			err = asError(last.Err)
+		}
+		h.recordSLO(c, err)
+	}
+}
+
+// ErrorMiddleware 适配Gin框架的错误处理中间件，处理通过c.Error添加的错误，
+// 所有错误都会被记录，只有最后一个错误会被渲染为响应，设置了SLO统计时每个请求都会计入统计
+func (h *Handler) ErrorMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(errorMiddlewareKey, true)
+
+		// 先处理请求
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			h.recordSLO(c, nil)
+			return
+		}
+
+		info := h.ginRequestInfo(c)
+		for _, ginErr := range c.Errors[:len(c.Errors)-1] {
+			h.recordError(info, asError(ginErr.Err))
+		}
+
+		err := asError(c.Errors.Last().Err)
+		if c.Writer.Written() {
+			h.recordError(info, err)
+		} else {
+			h.handleError(c.Writer, info, err)
+		}
+		h.recordSLO(c, err)
+	}
+}
+
+const (
+	// sloRecordedKey 标记请求已经计入SLO统计的Gin上下文键
+	sloRecordedKey = "go-errors/slo-recorded"
+	// errorMiddlewareKey 标记请求经过ErrorMiddleware的Gin上下文键
+	errorMiddlewareKey = "go-errors/error-middleware"
+)
+
+// recordSLO 按路由模板记录请求的SLO统计，恢复中间件和错误中间件都会调用，同一请求只记录一次
+func (h *Handler) recordSLO(c *gin.Context, err Error) {
+	if h.slo == nil {
+		return
+	}
+	if _, recorded := c.Get(sloRecordedKey); recorded {
+		return
+	}
+
+	c.Set(sloRecordedKey, true)
+	h.slo.Record(c.FullPath(), c.Writer.Status(), err)
+}
+
+// TimeoutMiddleware 适配Gin框架的请求超时处理中间件，为请求设置超时上下文，
+// 处理函数因上下文超时返回且未写入响应时，渲染超时错误
+func (h *Handler) TimeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			timeoutErr := Newf(ErrTimeout, "request timed out").
+				WithMetadata("method", c.Request.Method).
+				WithMetadata("path", c.Request.URL.Path)
+			h.handleError(c.Writer, h.ginRequestInfo(c), timeoutErr)
+			c.Abort()
+		}
+	}
+}
+
+// Recover 适配net/http的错误恢复中间件，可用于标准库ServeMux或chi等路由，
+// panic前已经写入响应时只记录错误，不再渲染错误响应。
+// 设置了SLO统计时按路由记录每个请求，通过WriteError或HandleFunc渲染的错误一并计入，
+// 需要作为最外层的中间件包住路由，路由模板默认取ServeMux匹配的Request.Pattern，
+// 其它路由可以通过WithHTTPRoute设置
+func (h *Handler) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tw := newTrackingWriter(w)
+		r, state := h.withHTTPSLO(r)
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				h.recordHTTPSLO(r, tw, state)
+				return
+			}
+
+			// http.ErrAbortHandler是标准库约定的中止信号，保持原有语义
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			err := NewPanicError(rec, debug.Stack())
+			info := h.httpRequestInfo(r)
+			h.logPanic(info, err)
+			if tw.Written() {
+				h.recordError(info, err)
+			} else {
+				h.handleError(tw, info, err)
+			}
+			if state != nil {
+				state.err = err
+			}
+			h.recordHTTPSLO(r, tw, state)
+		}()
+
+		next.ServeHTTP(tw, r)
+	})
+}
+
+// httpSLOKey 保存net/http请求SLO状态的上下文键
+type httpSLOKey struct{}
+
+// httpSLOState net/http请求的SLO状态，记录请求渲染的最后一个错误
+type httpSLOState struct {
+	err Error
+}
+
+// withHTTPSLO 在请求上下文中保存SLO状态，未设置SLO统计或外层已经保存时返回nil，由外层记录
+func (h *Handler) withHTTPSLO(r *http.Request) (*http.Request, *httpSLOState) {
+	if h.slo == nil {
+		return r, nil
+	}
+	if _, ok := r.Context().Value(httpSLOKey{}).(*httpSLOState); ok {
+		return r, nil
+	}
+
+	state := &httpSLOState{}
+	return r.WithContext(context.WithValue(r.Context(), httpSLOKey{}, state)), state
+}
+
+// recordHTTPSLO 按路由模板记录net/http请求的SLO统计，没有写入响应时按200处理
+func (h *Handler) recordHTTPSLO(r *http.Request, tw *trackingWriter, state *httpSLOState) {
+	if state == nil {
+		return
+	}
+
+	status := tw.Status()
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	route := r.Pattern
+	if h.httpRoute != nil {
+		route = h.httpRoute(r)
+	}
+	h.slo.Record(route, status, state.err)
+}
+
+// trackingWriter 记录是否已经写入响应和响应状态码的http.ResponseWriter
+type trackingWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// newTrackingWriter 包装http.ResponseWriter，已经是trackingWriter时直接返回
+func newTrackingWriter(w http.ResponseWriter) *trackingWriter {
+	if tw, ok := w.(*trackingWriter); ok {
+		return tw
+	}
+
+	return &trackingWriter{ResponseWriter: w}
+}
+
+func (w *trackingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *trackingWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 实现http.Flusher，底层不支持时不做任何处理
+func (w *trackingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		f.Flush()
+	}
+}
+
+// Unwrap 返回底层的http.ResponseWriter，供http.ResponseController使用
+func (w *trackingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Written 是否已经写入响应头
+func (w *trackingWriter) Written() bool {
+	return w.status != 0
+}
+
+// Status 返回响应状态码，未写入时返回0
+func (w *trackingWriter) Status() int {
+	return w.status
+}
+
+// ErrorHandlerFunc 返回错误的net/http处理函数
+type ErrorHandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// HandleFunc 将ErrorHandlerFunc适配为http.Handler，返回的错误会被记录并渲染为JSON响应
+func (h *Handler) HandleFunc(fn ErrorHandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := fn(w, r); err != nil {
+			h.WriteError(w, r, err)
+		}
+	})
+}
+
+// WriteError 记录错误并将其渲染为统一的JSON错误响应，非Error类型的错误经过DefaultClassifier分类，
+// 没有规则命中时按内部错误处理
+func (h *Handler) WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	e := asError(err)
+	if state, ok := r.Context().Value(httpSLOKey{}).(*httpSLOState); ok {
+		state.err = e
+	}
+	h.handleError(w, h.httpRequestInfo(r), e)
+}
+
+// handleError 处理错误，包括日志的记录、监控的记录和错误响应的构建
+func (h *Handler) handleError(w http.ResponseWriter, info requestInfo, err Error) {
+	h.recordError(info, err)
+	h.writeErrorResponse(w, info, err)
+}
+
+// recordError 记录错误到日志
+func (h *Handler) recordError(info requestInfo, err Error) {
+	h.monitor.Record(err)
+	h.recent.add(err, info)
+
+	// 采样丢弃的错误不记录日志，但仍然计入监控
+	if h.sampler != nil {
+		keep, summaries := h.sampler.Sample(err)
+		h.logSampleSummaries(summaries)
+		if !keep {
+			h.monitor.recordDropped()
+			return
+		}
+	}
+
+	// 记录结构化日志
+	fields := []zap.Field{
+		zap.String("code", err.Code()),
+		zap.String("type", err.Type().String()),
+		zap.String("message", h.redactor.RedactString(err.Message())),
+		zap.String("detail", h.redactor.RedactString(err.Detail())),
+		zap.String("path", info.path),
+		zap.String("method", info.method),
+		zap.Int("http_status", err.HttpStatus()),
+		zap.Time("timestamp", err.Timestamp()),
+	}
+
+	if cause := err.Unwrap(); cause != nil {
+		fields = append(fields, zap.String("cause", h.redactor.RedactString(cause.Error())))
+	}
+
+	// 记录SpanID信息，部分会叫做RequestID
+	if info.requestID != "" {
+		fields = append(fields, zap.String("request_id", info.requestID))
+	}
+
+	if info.spanID != "" {
+		fields = append(fields, zap.String("span_id", info.spanID))
+	}
+
+	if info.traceID != "" {
+		fields = append(fields, zap.String("trace_id", info.traceID))
+	}
+
+	// 记录客户端IP
+	if info.clientIP != "" {
+		fields = append(fields, zap.String("client_ip", info.clientIP))
+	}
+
+	// 记录聚合错误的子错误，聚合错误被包装时同样展开
+	var multi *MultiError
+	if errors.As(err, &multi) {
+		children := make([]string, 0, multi.Len())
+		for _, child := range multi.Errors() {
+			children = append(children, child.Code()+": "+h.redactor.RedactString(child.Error()))
+		}
+		fields = append(fields, zap.Strings("errors", children))
+	}
+
+	// 记录其他的元数据信息
+	if metadata := err.Metadata(); len(metadata) > 0 {
+		fields = append(fields, zap.Any("metadata", h.redactor.RedactMetadata(metadata)))
+	}
+
+	// 根据严重程度来决定日志记录的级别，未指定时按错误类型推导
+	severity := SeverityOf(err)
+	fields = append(fields, zap.String("severity", severity.String()))
+	switch severity {
+	case SeverityCritical:
+		h.l.Error("critical error", fields...)
+	case SeverityError:
+		h.l.Error("internal error", fields...)
+	case SeverityWarning:
+		h.l.Warn("business error", fields...)
+	case SeverityDebug:
+		h.l.Debug("debug error", fields...)
+	default:
+		h.l.Info("unknown error", fields...)
+	}
+}
+
+// FlushSampling 输出日志采样器中剩余的摘要，停止服务前调用，未设置采样器时不做任何处理
+func (h *Handler) FlushSampling() {
+	if h.sampler != nil {
+		h.logSampleSummaries(h.sampler.Flush())
+	}
+}
+
+// RunSampling 每个采样周期输出一次已结束周期的摘要，ctx结束时输出剩余的摘要后返回，
+// 设置了采样器时需要在单独的goroutine中运行，未设置采样器时直接返回
+//
+//	go h.RunSampling(ctx)
+func (h *Handler) RunSampling(ctx context.Context) {
+	if h.sampler != nil {
+		h.sampler.Run(ctx, h.logSampleSummaries)
+	}
+}
+
+// logSampleSummaries 输出采样摘要日志
+func (h *Handler) logSampleSummaries(summaries []SampleSummary) {
+	for _, summary := range summaries {
+		h.l.Warn(fmt.Sprintf("code %s occurred %d times in %gs", summary.Code, summary.Count, summary.Interval.Seconds()),
+			zap.String("code", summary.Code),
+			zap.String("sample_key", summary.Key),
+			zap.Int64("count", summary.Count),
+			zap.Int64("dropped", summary.Dropped),
+			zap.Time("start", summary.Start),
+			zap.Duration("interval", summary.Interval),
+		)
+	}
+}
+
+// buildErrorResponse 构建错误响应
+func (h *Handler) buildErrorResponse(info requestInfo, err Error, locale string) ErrorResponse {
+	errResponse := ErrorResponse{
+		Success:   false,
+		Code:      err.Code(),
+		Type:      err.Type(),
+		Message:   h.responseMessage(err, locale),
+		RequestID: info.requestID,
+		SpanID:    info.spanID,
+		TraceID:   info.traceID,
+		Timestamp: err.Timestamp().Format(time.RFC3339),
+	}
+
+	if h.showDetails && h.exposeInternal(err) {
+		if detail := err.Detail(); detail != err.Message() {
+			errResponse.Detail = h.redactor.RedactString(detail)
+		}
+		if metadata := err.Metadata(); len(metadata) > 0 {
+			errResponse.Details = h.redactor.RedactMetadata(metadata)
+		}
+	}
+
+	// 被包装的聚合错误和校验错误同样展开，聚合错误的子错误中的校验错误不提升到顶层
+	var multi *MultiError
+	var ve *ValidationError
+	if errors.As(err, &multi) {
+		for _, child := range multi.Errors() {
+			item := ErrorItem{
+				Code:    child.Code(),
+				Type:    child.Type(),
+				Message: h.responseMessage(child, locale),
+			}
+			if h.showDetails && h.exposeInternal(child) && len(child.Metadata()) > 0 {
+				item.Details = h.redactor.RedactMetadata(child.Metadata())
+			}
+			errResponse.Errors = append(errResponse.Errors, item)
+		}
+	} else if errors.As(err, &ve) {
+		for _, f := range ve.Fields() {
+			f.Message = h.redactor.RedactString(f.Message)
+			errResponse.Fields = append(errResponse.Fields, f)
+		}
+	}
+
+	return errResponse
+}
+
+// responseMessage 返回响应中展示的错误信息，不允许暴露内部信息时只返回面向用户的安全信息，
+// 面向用户的信息和未自定义的信息会按客户端语言进行本地化
+func (h *Handler) responseMessage(err Error, locale string) string {
+	if !h.exposeInternal(err) || err.Message() == err.PublicMessage() {
+		return h.redactor.RedactString(h.localize(err, locale))
+	}
+
+	return h.redactor.RedactString(err.Message())
+}
+
+// localize 返回面向用户信息的本地化结果，未设置翻译目录时返回PublicMessage
+func (h *Handler) localize(err Error, locale string) string {
+	if h.catalog == nil {
+		return err.PublicMessage()
+	}
+
+	return h.catalog.Localize(err, locale)
+}
+
+// negotiateLocale 根据客户端期望的语言选择响应语言，未设置翻译目录时返回空
+func (h *Handler) negotiateLocale(info requestInfo) string {
+	if h.catalog == nil {
+		return ""
+	}
+
+	return h.catalog.Match(info.locales...)
+}
+
+// exposeInternal 是否允许向客户端暴露错误的内部信息，生产环境或开启隐藏内部错误时，
+// INTERNAL和EXTERNAL类型的错误只返回面向用户的安全信息
+func (h *Handler) exposeInternal(err Error) bool {
+	if err.Type() != ErrTypeInternal && err.Type() != ErrTypeExternal {
+		return true
+	}
+
+	return !h.hideInternal && !h.isProduction()
+}
+
+// isProduction 是否为生产环境
+func (h *Handler) isProduction() bool {
+	switch strings.ToLower(h.environment) {
+	case "prod", "production":
+		return true
+	default:
+		return false
+	}
+}
+
+// logPanic 记录panic日志
+func (h *Handler) logPanic(info requestInfo, err *PanicError) {
+	h.l.Error("panic recovered",
+		zap.String("panic", h.redactor.RedactString(panicMessage(err.Value()))),
+		zap.String("panic_type", err.ValueType()),
+		zap.ByteString("stack", err.Stack()),
+		zap.String("path", info.path))
+}
+
+// writeErrorResponse 将错误响应以JSON格式写入
+func (h *Handler) writeErrorResponse(w http.ResponseWriter, info requestInfo, err Error) {
+	status := err.HttpStatus()
+	if status == 0 {
+		status = http.StatusInternalServerError
+	}
+
+	locale := h.negotiateLocale(info)
+	h.writeRetryHeaders(w.Header(), err)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if locale != "" {
+		w.Header().Set("Content-Language", locale)
+	}
+	w.WriteHeader(status)
+	if encodeErr := json.NewEncoder(w).Encode(h.buildErrorResponse(info, err, locale)); encodeErr != nil {
+		h.l.Error("write error response failed", zap.Error(encodeErr))
+	}
+}
+
+// writeRetryHeaders 写入Retry-After和RateLimit-*响应头，
+// RATE_LIMIT和TIMEOUT错误没有重试提示时使用默认的重试等待时间
+func (h *Handler) writeRetryHeaders(header http.Header, err Error) {
+	hint, _ := GetRetryHint(err)
+	if hint.RetryAfter <= 0 && h.defaultRetryAfter > 0 &&
+		(err.Type() == ErrTypeRateLimit || err.Type() == ErrTypeTimeout) {
+		hint.RetryAfter = h.defaultRetryAfter
+	}
+
+	writeRetryHeaders(header, hint)
+}
@@ -0,0 +1,336 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+func decodeErrorResponse(t *testing.T, rec *httptest.ResponseRecorder) ErrorResponse {
+	t.Helper()
+
+	var resp ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("解析错误响应失败: %v, body: %s", err, rec.Body.String())
+	}
+
+	return resp
+}
+
+// TestHandler_HTTP 测试net/http适配的错误处理
+func TestHandler_HTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       []HandlerOption
+		handler    ErrorHandlerFunc
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+	}{
+		{
+			name: "返回自定义错误",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return Newf(ErrNotFound, "user %d not found", 1)
+			},
+			wantStatus: http.StatusNotFound,
+			wantCode:   ErrNotFound.Code,
+			wantMsg:    "user 1 not found",
+		},
+		{
+			name: "返回标准错误",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return fmt.Errorf("db conn failed")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   ErrInternal.Code,
+			wantMsg:    ErrInternalMessage,
+		},
+		{
+			name: "隐藏内部错误",
+			opts: []HandlerOption{WithHideInternal()},
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				return InternalErrorf("db conn %s failed", "root:pwd@tcp")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   ErrInternal.Code,
+			wantMsg:    ErrInternalMessage,
+		},
+		{
+			name: "处理函数panic",
+			handler: func(w http.ResponseWriter, r *http.Request) error {
+				panic("boom")
+			},
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   ErrPanicRecovered.Code,
+			wantMsg:    "panic recovered: boom",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, tt.opts...)
+			srv := h.Recover(h.HandleFunc(tt.handler))
+
+			req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+			req.Header.Set(HeaderRequestID, "req-1")
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+
+			resp := decodeErrorResponse(t, rec)
+			if resp.Success {
+				t.Error("Success 应该为false")
+			}
+			if resp.Code != tt.wantCode {
+				t.Errorf("Code = %s, want %s", resp.Code, tt.wantCode)
+			}
+			if resp.Message != tt.wantMsg {
+				t.Errorf("Message = %s, want %s", resp.Message, tt.wantMsg)
+			}
+			if resp.RequestID != "req-1" {
+				t.Errorf("RequestID = %s, want req-1", resp.RequestID)
+			}
+		})
+	}
+}
+
+// TestHandler_GinAndHTTPEnvelope 测试Gin和net/http输出相同的响应结构
+func TestHandler_GinAndHTTPEnvelope(t *testing.T) {
+	h := NewHandler(nil, WithShowDetails())
+	newErr := func() Error {
+		return Newf(ErrConflict, "conflict").WithMetadata("id", "42")
+	}
+
+	engine := gin.New()
+	engine.Use(h.RecoveryMiddleware(), h.ErrorMiddleware())
+	engine.GET("/gin", func(c *gin.Context) {
+		_ = c.Error(newErr())
+	})
+	ginRec := httptest.NewRecorder()
+	engine.ServeHTTP(ginRec, httptest.NewRequest(http.MethodGet, "/gin", nil))
+
+	httpRec := httptest.NewRecorder()
+	h.WriteError(httpRec, httptest.NewRequest(http.MethodGet, "/http", nil), newErr())
+
+	if ginRec.Code != httpRec.Code || ginRec.Code != http.StatusConflict {
+		t.Fatalf("status gin = %d, http = %d, want %d", ginRec.Code, httpRec.Code, http.StatusConflict)
+	}
+
+	ginResp, httpResp := decodeErrorResponse(t, ginRec), decodeErrorResponse(t, httpRec)
+	ginResp.Timestamp, httpResp.Timestamp = "", ""
+	if fmt.Sprint(ginResp) != fmt.Sprint(httpResp) {
+		t.Errorf("gin响应 %+v 与 http响应 %+v 不一致", ginResp, httpResp)
+	}
+	if ginResp.Details["id"] != "42" {
+		t.Errorf("Details[id] = %v, want 42", ginResp.Details["id"])
+	}
+}
+
+// TestHandler_PublicMessage 测试根据环境和错误类型选择返回的信息
+func TestHandler_PublicMessage(t *testing.T) {
+	newErr := func() Error {
+		return NewBuilder().
+			WithCode(ErrInternal).
+			WithMessage("db conn failed").
+			WithDetail("dial tcp 10.0.0.1:3306").
+			WithMetadata("table", "users").
+			Build()
+	}
+
+	tests := []struct {
+		name       string
+		opts       []HandlerOption
+		err        Error
+		wantMsg    string
+		wantDetail string
+	}{
+		{
+			name:       "开发环境返回内部详情",
+			opts:       []HandlerOption{WithShowDetails()},
+			err:        newErr(),
+			wantMsg:    "db conn failed",
+			wantDetail: "dial tcp 10.0.0.1:3306",
+		},
+		{
+			name:    "生产环境内部错误只返回公开消息",
+			opts:    []HandlerOption{WithShowDetails(), WithEnvironment("production")},
+			err:     newErr(),
+			wantMsg: ErrInternalMessage,
+		},
+		{
+			name:    "生产环境业务错误返回消息",
+			opts:    []HandlerOption{WithEnvironment("prod")},
+			err:     Newf(ErrNotFound, "user 1 not found"),
+			wantMsg: "user 1 not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewHandler(nil, tt.opts...).WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			resp := decodeErrorResponse(t, rec)
+			if resp.Message != tt.wantMsg {
+				t.Errorf("Message = %s, want %s", resp.Message, tt.wantMsg)
+			}
+			if resp.Detail != tt.wantDetail {
+				t.Errorf("Detail = %s, want %s", resp.Detail, tt.wantDetail)
+			}
+			if tt.wantDetail == "" && resp.Details != nil {
+				t.Errorf("Details = %v, want nil", resp.Details)
+			}
+		})
+	}
+}
+
+// TestHandler_ClientIP 测试只有直连地址属于可信代理时才使用X-Forwarded-For
+func TestHandler_ClientIP(t *testing.T) {
+	trusted := WithTrustedProxies(netip.MustParsePrefix("10.0.0.0/8"))
+	tests := []struct {
+		name      string
+		opts      []HandlerOption
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{name: "未设置可信代理", remote: "1.2.3.4:5678", forwarded: "9.9.9.9", want: "1.2.3.4"},
+		{name: "直连地址不可信", opts: []HandlerOption{trusted}, remote: "1.2.3.4:5678", forwarded: "9.9.9.9", want: "1.2.3.4"},
+		{name: "可信代理转发", opts: []HandlerOption{trusted}, remote: "10.0.0.1:5678", forwarded: "9.9.9.9", want: "9.9.9.9"},
+		{name: "跳过多级可信代理", opts: []HandlerOption{trusted}, remote: "10.0.0.1:5678", forwarded: "6.6.6.6, 9.9.9.9, 10.0.0.2", want: "9.9.9.9"},
+		{name: "全部为可信代理", opts: []HandlerOption{trusted}, remote: "10.0.0.1:5678", forwarded: "10.0.0.3", want: "10.0.0.3"},
+		{name: "没有转发头", opts: []HandlerOption{trusted}, remote: "10.0.0.1:5678", want: "10.0.0.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHandler(nil, tt.opts...)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+
+			if got := h.httpRequestInfo(req).clientIP; got != tt.want {
+				t.Errorf("clientIP = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestHandler_RecoverAfterWrite 测试panic前已经写入响应时不再渲染错误响应
+func TestHandler_RecoverAfterWrite(t *testing.T) {
+	m := NewMonitor()
+	h := NewHandler(nil, WithMonitor(m))
+	srv := h.Recover(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte("partial"))
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusAccepted)
+	}
+	if rec.Body.String() != "partial" {
+		t.Errorf("body = %q, want partial", rec.Body.String())
+	}
+	if got := m.Snapshot().ByCode[ErrPanicRecovered.Code]; got != 1 {
+		t.Errorf("panic错误记录次数 = %d, want 1", got)
+	}
+}
+
+// TestHandler_RecoveryMiddlewareAfterWrite 测试Gin中panic前已经写入响应时不再追加错误响应
+func TestHandler_RecoveryMiddlewareAfterWrite(t *testing.T) {
+	m := NewMonitor()
+	h := NewHandler(nil, WithMonitor(m))
+	engine := gin.New()
+	engine.Use(h.RecoveryMiddleware())
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusAccepted, "partial")
+		panic("boom")
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Errorf("status = %d, body = %q", rec.Code, rec.Body.String())
+	}
+	if got := m.Snapshot().ByCode[ErrPanicRecovered.Code]; got != 1 {
+		t.Errorf("panic错误记录次数 = %d, want 1", got)
+	}
+}
+
+// TestHandler_ClassifiedStatus 测试非Error类型的错误经过DefaultClassifier分类后的响应状态码
+func TestHandler_ClassifiedStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{name: "sql.ErrNoRows", err: fmt.Errorf("query user: %w", sql.ErrNoRows), wantStatus: http.StatusNotFound, wantCode: ErrNotFound.Code},
+		{name: "os.ErrNotExist", err: os.ErrNotExist, wantStatus: http.StatusNotFound, wantCode: ErrNotFound.Code},
+		{name: "os.ErrPermission", err: os.ErrPermission, wantStatus: http.StatusForbidden, wantCode: ErrForbidden.Code},
+		{name: "context.DeadlineExceeded", err: context.DeadlineExceeded, wantStatus: http.StatusRequestTimeout, wantCode: ErrTimeout.Code},
+		{name: "context.Canceled", err: context.Canceled, wantStatus: StatusClientClosedRequest, wantCode: ErrCanceled.Code},
+		{name: "未知错误", err: fmt.Errorf("boom"), wantStatus: http.StatusInternalServerError, wantCode: ErrInternal.Code},
+	}
+
+	h := NewHandler(nil)
+	engine := gin.New()
+	engine.Use(h.ErrorMiddleware())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine.GET("/"+tt.name, func(c *gin.Context) {
+				_ = c.Error(tt.err)
+			})
+			ginRec := httptest.NewRecorder()
+			engine.ServeHTTP(ginRec, httptest.NewRequest(http.MethodGet, "/"+tt.name, nil))
+
+			httpRec := httptest.NewRecorder()
+			h.WriteError(httpRec, httptest.NewRequest(http.MethodGet, "/", nil), tt.err)
+
+			for adapter, rec := range map[string]*httptest.ResponseRecorder{"gin": ginRec, "http": httpRec} {
+				if rec.Code != tt.wantStatus {
+					t.Errorf("%s status = %d, want %d", adapter, rec.Code, tt.wantStatus)
+				}
+				if resp := decodeErrorResponse(t, rec); resp.Code != tt.wantCode {
+					t.Errorf("%s Code = %s, want %s", adapter, resp.Code, tt.wantCode)
+				}
+			}
+		})
+	}
+}
@@ -1 +1,208 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
 package errors
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Monitor 错误监控，按错误码、错误类型和HTTP状态码统计错误次数，并汇总熔断器和SLO的状态
+type Monitor struct {
+	mu sync.RWMutex
+	// 时钟
+	clock Clock
+	// 错误总数
+	total int64
+	// 按错误码统计
+	byCode map[string]int64
+	// 按错误类型统计
+	byType map[ErrType]int64
+	// 按HTTP状态码统计
+	byStatus map[int]int64
+	// 按严重程度统计
+	bySeverity map[Severity]int64
+	// 被日志采样丢弃的错误数
+	dropped int64
+	// 注册的熔断器
+	breakers map[string]*CircuitBreaker
+	// 注册的SLO统计
+	slos *SLOTracker
+	// Prometheus指标名的前缀
+	namespace string
+}
+
+type MonitorOption func(m *Monitor)
+
+// WithPrometheusNamespace 设置Prometheus指标名的前缀，如app输出app_errors_total，
+// 避免与其它导出器的同名指标冲突，默认没有前缀
+func WithPrometheusNamespace(namespace string) MonitorOption {
+	return func(m *Monitor) {
+		m.namespace = namespace
+	}
+}
+
+// WithMonitorClock 设置监控使用的时钟，默认为系统时钟
+func WithMonitorClock(clock Clock) MonitorOption {
+	return func(m *Monitor) {
+		m.clock = clock
+	}
+}
+
+// NewMonitor 创建错误监控
+func NewMonitor(opts ...MonitorOption) *Monitor {
+	m := &Monitor{
+		clock:      SystemClock{},
+		byCode:     make(map[string]int64),
+		byType:     make(map[ErrType]int64),
+		byStatus:   make(map[int]int64),
+		bySeverity: make(map[Severity]int64),
+		breakers:   make(map[string]*CircuitBreaker),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return m
+}
+
+// Record 记录一次错误，nil会被忽略
+func (m *Monitor) Record(err Error) {
+	if m == nil || err == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.total++
+	m.byCode[err.Code()]++
+	m.byType[err.Type()]++
+	m.byStatus[err.HttpStatus()]++
+	m.bySeverity[SeverityOf(err)]++
+}
+
+// recordDropped 记录一次被日志采样丢弃的错误，错误本身已经通过Record计入统计
+func (m *Monitor) recordDropped() {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.dropped++
+}
+
+// RegisterBreaker 注册熔断器，熔断器的状态会出现在监控快照中
+func (m *Monitor) RegisterBreaker(b *CircuitBreaker) {
+	if m == nil || b == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.breakers[b.Name()] = b
+}
+
+// RegisterSLOTracker 注册SLO统计，各路由的SLO状态会出现在监控快照中
+func (m *Monitor) RegisterSLOTracker(t *SLOTracker) {
+	if m == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.slos = t
+}
+
+// MonitorSnapshot 监控快照
+type MonitorSnapshot struct {
+	// 快照时间
+	Timestamp time.Time `json:"timestamp"`
+	// 错误总数
+	Total int64 `json:"total"`
+	// 按错误码统计
+	ByCode map[string]int64 `json:"byCode"`
+	// 按错误类型统计
+	ByType map[ErrType]int64 `json:"byType"`
+	// 按HTTP状态码统计
+	ByStatus map[int]int64 `json:"byStatus"`
+	// 按严重程度统计
+	BySeverity map[Severity]int64 `json:"bySeverity"`
+	// 被日志采样丢弃的错误数
+	Dropped int64 `json:"dropped"`
+	// 熔断器状态，按名称排序
+	Breakers []BreakerSnapshot `json:"breakers,omitempty"`
+	// 各路由的SLO状态，按路由排序
+	SLOs []SLOSnapshot `json:"slos,omitempty"`
+}
+
+// Snapshot 返回当前的监控快照，nil监控返回没有任何统计的空快照
+func (m *Monitor) Snapshot() MonitorSnapshot {
+	if m == nil {
+		return MonitorSnapshot{
+			Timestamp:  SystemClock{}.Now(),
+			ByCode:     map[string]int64{},
+			ByType:     map[ErrType]int64{},
+			ByStatus:   map[int]int64{},
+			BySeverity: map[Severity]int64{},
+		}
+	}
+
+	m.mu.RLock()
+	snapshot := MonitorSnapshot{
+		Timestamp:  m.clock.Now(),
+		Total:      m.total,
+		Dropped:    m.dropped,
+		ByCode:     make(map[string]int64, len(m.byCode)),
+		ByType:     make(map[ErrType]int64, len(m.byType)),
+		ByStatus:   make(map[int]int64, len(m.byStatus)),
+		BySeverity: make(map[Severity]int64, len(m.bySeverity)),
+	}
+	for k, v := range m.byCode {
+		snapshot.ByCode[k] = v
+	}
+	for k, v := range m.byType {
+		snapshot.ByType[k] = v
+	}
+	for k, v := range m.byStatus {
+		snapshot.ByStatus[k] = v
+	}
+	for k, v := range m.bySeverity {
+		snapshot.BySeverity[k] = v
+	}
+	breakers := make([]*CircuitBreaker, 0, len(m.breakers))
+	for _, b := range m.breakers {
+		breakers = append(breakers, b)
+	}
+	slos := m.slos
+	m.mu.RUnlock()
+
+	// 熔断器和SLO统计有自己的锁，释放监控的锁之后再读取
+	snapshot.SLOs = slos.Snapshot()
+	for _, b := range breakers {
+		snapshot.Breakers = append(snapshot.Breakers, b.Snapshot())
+	}
+	sort.Slice(snapshot.Breakers, func(i, j int) bool {
+		return snapshot.Breakers[i].Name < snapshot.Breakers[j].Name
+	})
+
+	return snapshot
+}
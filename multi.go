@@ -0,0 +1,347 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AggregatePolicy 多错误聚合时选取代表错误的策略，代表错误决定聚合后的Code、Type和HttpStatus
+type AggregatePolicy int
+
+const (
+	// AggregateWorst 选取HTTP状态码最大的错误，状态码相同时选取最先加入的错误
+	AggregateWorst AggregatePolicy = iota
+	// AggregateFirst 选取最先加入的错误
+	AggregateFirst
+	// AggregateMostCommon 选取出现次数最多的错误类型中最先加入的错误
+	AggregateMostCommon
+	// AggregateLast 选取最后加入的错误，例如重试时以最后一次尝试的结果为准
+	AggregateLast
+)
+
+// MultiError 多个错误的聚合，例如批量导入中的多行失败或并发调用中的部分失败
+// Error接口要求Unwrap() error，因此Unwrap返回errors.Join的结果，其Unwrap() []error
+// 包含所有子错误，errors.Is和errors.As可以匹配到任意子错误
+type MultiError struct {
+	// 子错误
+	errs []Error
+	// 聚合策略
+	policy AggregatePolicy
+	// 时间戳
+	timestamp time.Time
+	// 聚合错误自身的元数据
+	metadata map[string]any
+}
+
+// NewMultiError 创建指定聚合策略的多错误
+func NewMultiError(policy AggregatePolicy) *MultiError {
+	return &MultiError{
+		policy:    policy,
+		timestamp: time.Now().UTC(),
+		metadata:  make(map[string]any),
+	}
+}
+
+// Join 使用AggregateWorst策略聚合多个错误，所有错误都为nil时返回nil
+func Join(errs ...error) Error {
+	return NewMultiError(AggregateWorst).Append(errs...).ErrorOrNil()
+}
+
+// Append 添加错误，nil会被忽略，非Error类型的错误使用DefaultClassifier包装，嵌套的MultiError会被展开
+func (m *MultiError) Append(errs ...error) *MultiError {
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		if nested, ok := err.(*MultiError); ok {
+			if nested != m {
+				m.errs = append(m.errs, nested.errs...)
+			}
+			continue
+		}
+
+		m.errs = append(m.errs, asError(err))
+	}
+
+	return m
+}
+
+// Len 返回子错误数量
+func (m *MultiError) Len() int {
+	return len(m.errs)
+}
+
+// Errors 返回所有子错误的副本
+func (m *MultiError) Errors() []Error {
+	return append([]Error(nil), m.errs...)
+}
+
+// ErrorOrNil 没有子错误时返回nil，避免返回带类型的nil接口
+func (m *MultiError) ErrorOrNil() Error {
+	if m == nil || len(m.errs) == 0 {
+		return nil
+	}
+
+	return m
+}
+
+// Primary 按聚合策略返回代表错误，没有子错误时返回nil
+func (m *MultiError) Primary() Error {
+	if len(m.errs) == 0 {
+		return nil
+	}
+
+	switch m.policy {
+	case AggregateFirst:
+		return m.errs[0]
+	case AggregateLast:
+		return m.errs[len(m.errs)-1]
+	case AggregateMostCommon:
+		counts := make(map[ErrType]int, len(m.errs))
+		for _, err := range m.errs {
+			counts[err.Type()]++
+		}
+		primary := m.errs[0]
+		for _, err := range m.errs[1:] {
+			if counts[err.Type()] > counts[primary.Type()] {
+				primary = err
+			}
+		}
+		return primary
+	default:
+		primary := m.errs[0]
+		for _, err := range m.errs[1:] {
+			if err.HttpStatus() > primary.HttpStatus() {
+				primary = err
+			}
+		}
+		return primary
+	}
+}
+
+func (m *MultiError) Error() string {
+	switch len(m.errs) {
+	case 0:
+		return ""
+	case 1:
+		return m.errs[0].Error()
+	}
+
+	var sb strings.Builder
+	sb.WriteString(strconv.Itoa(len(m.errs)))
+	sb.WriteString(" errors occurred: ")
+	for i, err := range m.errs {
+		if i > 0 {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(err.Error())
+	}
+
+	return sb.String()
+}
+
+func (m *MultiError) Code() string {
+	if primary := m.Primary(); primary != nil {
+		return primary.Code()
+	}
+
+	return ""
+}
+
+func (m *MultiError) Message() string {
+	switch len(m.errs) {
+	case 0:
+		return ""
+	case 1:
+		return m.errs[0].Message()
+	default:
+		return fmt.Sprintf("%d errors occurred", len(m.errs))
+	}
+}
+
+func (m *MultiError) PublicMessage() string {
+	if primary := m.Primary(); primary != nil {
+		return primary.PublicMessage()
+	}
+
+	return ""
+}
+
+// MessageKey 返回主错误在翻译目录中查找本地化消息使用的键
+func (m *MultiError) MessageKey() string {
+	if primary := m.Primary(); primary != nil {
+		return messageKeyOf(primary)
+	}
+
+	return ""
+}
+
+// Detail 返回所有子错误的内部详情
+func (m *MultiError) Detail() string {
+	details := make([]string, 0, len(m.errs))
+	for _, err := range m.errs {
+		details = append(details, err.Detail())
+	}
+
+	return strings.Join(details, "; ")
+}
+
+func (m *MultiError) HttpStatus() int {
+	if primary := m.Primary(); primary != nil {
+		return primary.HttpStatus()
+	}
+
+	return 0
+}
+
+func (m *MultiError) Type() ErrType {
+	if primary := m.Primary(); primary != nil {
+		return primary.Type()
+	}
+
+	return ""
+}
+
+// Severity 返回子错误中最高的严重程度
+func (m *MultiError) Severity() Severity {
+	severity := SeverityByType
+	for _, err := range m.errs {
+		severity = max(severity, SeverityOf(err))
+	}
+
+	return severity
+}
+
+func (m *MultiError) Timestamp() time.Time {
+	return m.timestamp
+}
+
+// StackTrace 返回代表错误的堆栈信息
+func (m *MultiError) StackTrace() string {
+	if primary := m.Primary(); primary != nil {
+		return primary.StackTrace()
+	}
+
+	return ""
+}
+
+func (m *MultiError) Metadata() map[string]any {
+	return m.metadata
+}
+
+func (m *MultiError) WithMetadata(key string, val any) Error {
+	m.metadata[key] = val
+	return m
+}
+
+func (m *MultiError) WithMetadataMap(metadata map[string]any) Error {
+	for k, v := range metadata {
+		m.metadata[k] = v
+	}
+
+	return m
+}
+
+// Unwrap 返回包含所有子错误的errors.Join结果
+func (m *MultiError) Unwrap() error {
+	if len(m.errs) == 0 {
+		return nil
+	}
+
+	errs := make([]error, len(m.errs))
+	for i, err := range m.errs {
+		errs[i] = err
+	}
+
+	return errors.Join(errs...)
+}
+
+// Format 实现fmt.Formatter，%+v逐条输出子错误的错误码、信息和堆栈
+func (m *MultiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = fmt.Fprintf(s, "%d errors occurred:\n", len(m.errs))
+			for i, err := range m.errs {
+				_, _ = fmt.Fprintf(s, "  [%d] %s (%s, %d): %s\n", i, err.Code(), err.Type(), err.HttpStatus(), err.Error())
+				if stack := err.StackTrace(); stack != "" {
+					for _, line := range strings.Split(strings.TrimRight(stack, "\n"), "\n") {
+						_, _ = io.WriteString(s, "      "+line+"\n")
+					}
+				}
+			}
+			return
+		}
+		_, _ = io.WriteString(s, m.Error())
+	case 's':
+		_, _ = io.WriteString(s, m.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", m.Error())
+	}
+}
+
+// multiErrorItem 子错误的JSON结构
+type multiErrorItem struct {
+	Code       string         `json:"code"`
+	Type       ErrType        `json:"type"`
+	HttpStatus int            `json:"httpStatus"`
+	Message    string         `json:"message"`
+	Metadata   map[string]any `json:"metadata,omitempty"`
+}
+
+// MarshalJSON 输出聚合后的错误码、类型、状态码以及所有子错误，
+// 错误信息和元数据经过DefaultRedactor脱敏，需要其它脱敏器时使用MarshalJSONWith
+func (m *MultiError) MarshalJSON() ([]byte, error) {
+	return m.MarshalJSONWith(DefaultRedactor())
+}
+
+// MarshalJSONWith 与MarshalJSON相同，错误信息和元数据经过r脱敏，r为nil时不脱敏，
+// Handler渲染聚合错误时使用自身的脱敏器，不经过MarshalJSON
+func (m *MultiError) MarshalJSONWith(r *Redactor) ([]byte, error) {
+	items := make([]multiErrorItem, 0, len(m.errs))
+	for _, err := range m.errs {
+		items = append(items, multiErrorItem{
+			Code:       err.Code(),
+			Type:       err.Type(),
+			HttpStatus: err.HttpStatus(),
+			Message:    r.RedactString(err.Message()),
+			Metadata:   r.RedactMetadata(err.Metadata()),
+		})
+	}
+
+	return json.Marshal(struct {
+		Code       string           `json:"code"`
+		Type       ErrType          `json:"type"`
+		HttpStatus int              `json:"httpStatus"`
+		Message    string           `json:"message"`
+		Metadata   map[string]any   `json:"metadata,omitempty"`
+		Errors     []multiErrorItem `json:"errors"`
+	}{
+		Code:       m.Code(),
+		Type:       m.Type(),
+		HttpStatus: m.HttpStatus(),
+		Message:    r.RedactString(m.Message()),
+		Metadata:   r.RedactMetadata(m.metadata),
+		Errors:     items,
+	})
+}
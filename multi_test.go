@@ -0,0 +1,196 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMultiError_Policy 测试不同聚合策略下的代表错误
+func TestMultiError_Policy(t *testing.T) {
+	newErrs := func() []error {
+		return []error{
+			FastNew(ErrBadRequest),
+			FastNew(ErrNotFound),
+			FastNew(ErrNotFound),
+			FastNew(ErrInternal),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		policy     AggregatePolicy
+		wantType   ErrType
+		wantStatus int
+	}{
+		{name: "最严重", policy: AggregateWorst, wantType: ErrTypeInternal, wantStatus: http.StatusInternalServerError},
+		{name: "第一个", policy: AggregateFirst, wantType: ErrTypeBadRequest, wantStatus: http.StatusBadRequest},
+		{name: "最常见", policy: AggregateMostCommon, wantType: ErrTypeNotFound, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMultiError(tt.policy).Append(newErrs()...)
+			if m.Type() != tt.wantType {
+				t.Errorf("Type() = %v, want %v", m.Type(), tt.wantType)
+			}
+			if m.HttpStatus() != tt.wantStatus {
+				t.Errorf("HttpStatus() = %v, want %v", m.HttpStatus(), tt.wantStatus)
+			}
+		})
+	}
+}
+
+// TestMultiError_Unwrap 测试errors.Is和errors.As可以匹配子错误
+func TestMultiError_Unwrap(t *testing.T) {
+	sentinel := fmt.Errorf("row 3 invalid")
+	err := Join(nil, FastNew(ErrBadRequest), sentinel, nil)
+
+	var m *MultiError
+	if !errors.As(err, &m) || m.Len() != 2 {
+		t.Fatalf("期望得到2个子错误的MultiError，但得到了 %v", err)
+	}
+	if !errors.Is(err, sentinel) {
+		t.Error("errors.Is应该匹配到子错误")
+	}
+
+	joined, ok := m.Unwrap().(interface{ Unwrap() []error })
+	if !ok || len(joined.Unwrap()) != 2 {
+		t.Error("Unwrap()应该返回实现了Unwrap() []error的错误")
+	}
+
+	if Join(nil, nil) != nil {
+		t.Error("所有错误都为nil时Join应该返回nil")
+	}
+
+	// 嵌套的MultiError会被展开
+	nested := NewMultiError(AggregateFirst).Append(err, FastNew(ErrConflict))
+	if nested.Len() != 3 {
+		t.Errorf("Len() = %d, want 3", nested.Len())
+	}
+}
+
+// TestMultiError_Render 测试%+v、JSON和HTTP响应的输出
+func TestMultiError_Render(t *testing.T) {
+	err := Join(
+		Newf(ErrBadRequest, "row 1: name is required"),
+		Newf(ErrConflict, "row 2: email exists"),
+	)
+
+	verbose := fmt.Sprintf("%+v", err)
+	if !strings.Contains(verbose, "2 errors occurred") || !strings.Contains(verbose, "row 2: email exists") {
+		t.Errorf("%%+v输出不完整: %s", verbose)
+	}
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatalf("json.Marshal() error = %v", marshalErr)
+	}
+	var decoded struct {
+		HttpStatus int `json:"httpStatus"`
+		Errors     []struct {
+			Code string `json:"code"`
+		} `json:"errors"`
+	}
+	if unmarshalErr := json.Unmarshal(data, &decoded); unmarshalErr != nil {
+		t.Fatalf("json.Unmarshal() error = %v", unmarshalErr)
+	}
+	if decoded.HttpStatus != http.StatusConflict || len(decoded.Errors) != 2 {
+		t.Errorf("JSON输出不正确: %s", data)
+	}
+
+	rec := httptest.NewRecorder()
+	NewHandler(nil).WriteError(rec, httptest.NewRequest(http.MethodPost, "/import", nil), err)
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusConflict)
+	}
+	resp := decodeErrorResponse(t, rec)
+	if len(resp.Errors) != 2 || resp.Errors[0].Message != "row 1: name is required" {
+		t.Errorf("Errors = %+v", resp.Errors)
+	}
+}
+
+// TestMultiError_MarshalJSONRedact 测试JSON输出经过默认脱敏器脱敏
+func TestMultiError_MarshalJSONRedact(t *testing.T) {
+	err := Join(
+		Newf(ErrBadRequest, "contact bob@example.com").WithMetadata("password", "p@ss"),
+		Newf(ErrConflict, "conflict"),
+	)
+
+	data, marshalErr := json.Marshal(err)
+	if marshalErr != nil {
+		t.Fatalf("json.Marshal() error = %v", marshalErr)
+	}
+	if s := string(data); strings.Contains(s, "bob@example.com") || strings.Contains(s, "p@ss") {
+		t.Errorf("JSON输出包含敏感信息: %s", s)
+	}
+
+	data, _ = err.(*MultiError).MarshalJSONWith(nil)
+	if s := string(data); !strings.Contains(s, "bob@example.com") || !strings.Contains(s, "p@ss") {
+		t.Errorf("关闭脱敏后应该输出原值: %s", s)
+	}
+
+	custom := NewRedactor(WithRedactKeys("code_name"))
+	data, _ = Join(Newf(ErrConflict, "bob@example.com").WithMetadata("code_name", "x")).(*MultiError).MarshalJSONWith(custom)
+	if s := string(data); !strings.Contains(s, "bob@example.com") || strings.Contains(s, `"x"`) {
+		t.Errorf("应该使用指定的脱敏器: %s", s)
+	}
+
+	// Handler使用自身的脱敏器渲染子错误
+	rec := httptest.NewRecorder()
+	NewHandler(nil, WithRedactor(nil), WithShowDetails()).
+		WriteError(rec, httptest.NewRequest(http.MethodGet, "/", nil), err)
+	if !strings.Contains(rec.Body.String(), "bob@example.com") {
+		t.Errorf("关闭脱敏的Handler应该输出子错误原值: %s", rec.Body.String())
+	}
+}
+
+// TestHandler_WrappedAggregate 测试被包装的聚合错误和校验错误同样展开子错误和字段
+func TestHandler_WrappedAggregate(t *testing.T) {
+	multi := Join(Newf(ErrBadRequest, "row 1"), Newf(ErrConflict, "row 2"))
+	ve := NewValidationError().AddField("name", "required", "", "name is required")
+
+	tests := []struct {
+		name       string
+		err        error
+		wantErrors int
+		wantFields int
+	}{
+		{name: "聚合错误作为原始错误", err: NewBuilder().WithCode(ErrBadRequest).WithCause(multi).Build(), wantErrors: 2},
+		{name: "标准库包装聚合错误", err: fmt.Errorf("import: %w", multi), wantErrors: 2},
+		{name: "校验错误作为原始错误", err: NewBuilder().WithCode(ErrBadRequest).WithCause(ve).Build(), wantFields: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			NewHandler(nil).WriteError(rec, httptest.NewRequest(http.MethodPost, "/", nil), tt.err)
+
+			resp := decodeErrorResponse(t, rec)
+			if len(resp.Errors) != tt.wantErrors {
+				t.Errorf("len(Errors) = %d, want %d", len(resp.Errors), tt.wantErrors)
+			}
+			if len(resp.Fields) != tt.wantFields {
+				t.Errorf("len(Fields) = %d, want %d", len(resp.Fields), tt.wantFields)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// AlertNotifier 告警通知器，告警触发、恢复以及重复通知时调用
+type AlertNotifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// AlertNotifierFunc 函数形式的告警通知器
+type AlertNotifierFunc func(ctx context.Context, alert Alert) error
+
+func (f AlertNotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// LogNotifier 将告警写入日志，触发的告警按严重程度选择日志级别，恢复的告警使用Info级别
+func LogNotifier(l *zap.Logger) AlertNotifier {
+	if l == nil {
+		l = zap.NewNop()
+	}
+
+	return AlertNotifierFunc(func(_ context.Context, alert Alert) error {
+		fields := []zap.Field{
+			zap.String("rule", alert.Rule),
+			zap.String("state", alert.State.String()),
+			zap.String("severity", alert.Severity.String()),
+			zap.String("description", alert.Description),
+			zap.Float64("value", alert.Value),
+		}
+		for k, v := range alert.Labels {
+			fields = append(fields, zap.String("label."+k, v))
+		}
+
+		if alert.State != AlertFiring {
+			l.Info("alert "+alert.State.String(), fields...)
+			return nil
+		}
+
+		switch alert.Severity {
+		case SeverityCritical, SeverityError:
+			l.Error("alert firing", fields...)
+		case SeverityWarning:
+			l.Warn("alert firing", fields...)
+		default:
+			l.Info("alert firing", fields...)
+		}
+		return nil
+	})
+}
+
+// ChannelNotifier 将告警发送到通道，通道已满时阻塞直到ctx结束
+func ChannelNotifier(ch chan<- Alert) AlertNotifier {
+	return AlertNotifierFunc(func(ctx context.Context, alert Alert) error {
+		select {
+		case ch <- alert:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// WebhookNotifier 将告警以JSON格式POST到url，非2xx响应通过DecodeResponse转换为错误，
+// client为空时使用http.DefaultClient
+func WebhookNotifier(url string, client *http.Client) AlertNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return AlertNotifierFunc(func(ctx context.Context, alert Alert) error {
+		body, err := json.Marshal(alert)
+		if err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if decoded := DecodeResponse(resp); decoded != nil {
+			return decoded
+		}
+
+		return nil
+	})
+}
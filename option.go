@@ -14,33 +14,98 @@
 
 package errors
 
-//
-//type HandlerOption func(h *Handler)
-//
-//// WithShowDetails 是否显示错误详情，默认为false
-//func WithShowDetails() HandlerOption {
-//	return func(h *Handler) {
-//		h.showDetails = true
-//	}
-//}
-//
-//// WithHideInternal 是否隐藏内部错误，默认为false
-//func WithHideInternal() HandlerOption {
-//	return func(h *Handler) {
-//		h.hideInternal = true
-//	}
-//}
-//
-//// WithEnableMonitor 是否启用监控，默认为false
-//func WithEnableMonitor() HandlerOption {
-//	return func(h *Handler) {
-//		h.enableMonitor = true
-//	}
-//}
-//
-//// WithEnvironment 设置环境变量，默认为dev
-//func WithEnvironment(env string) HandlerOption {
-//	return func(h *Handler) {
-//		h.environment = env
-//	}
-//}
+import (
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+type HandlerOption func(h *Handler)
+
+// WithShowDetails 是否显示错误详情，默认为false
+func WithShowDetails() HandlerOption {
+	return func(h *Handler) {
+		h.showDetails = true
+	}
+}
+
+// WithHideInternal 是否隐藏内部错误，默认为false
+func WithHideInternal() HandlerOption {
+	return func(h *Handler) {
+		h.hideInternal = true
+	}
+}
+
+// WithEnvironment 设置环境变量，默认为dev，prod和production视为生产环境
+func WithEnvironment(env string) HandlerOption {
+	return func(h *Handler) {
+		h.environment = env
+	}
+}
+
+// WithRedactor 设置脱敏器，默认为DefaultRedactor，传入nil时关闭脱敏
+func WithRedactor(r *Redactor) HandlerOption {
+	return func(h *Handler) {
+		h.redactor = r
+	}
+}
+
+// WithCatalog 设置翻译目录，默认为DefaultCatalog，传入nil时关闭本地化
+func WithCatalog(c *Catalog) HandlerOption {
+	return func(h *Handler) {
+		h.catalog = c
+	}
+}
+
+// WithDefaultRetryAfter 设置RATE_LIMIT和TIMEOUT错误没有重试提示时响应的Retry-After，默认不设置
+func WithDefaultRetryAfter(d time.Duration) HandlerOption {
+	return func(h *Handler) {
+		h.defaultRetryAfter = d
+	}
+}
+
+// WithMonitor 设置错误监控，处理的错误会计入监控统计，默认不设置
+func WithMonitor(m *Monitor) HandlerOption {
+	return func(h *Handler) {
+		h.monitor = m
+	}
+}
+
+// WithRecentErrors 设置最近错误缓冲区，处理的错误会连同请求信息一起记录，默认不设置
+func WithRecentErrors(r *RecentErrors) HandlerOption {
+	return func(h *Handler) {
+		h.recent = r
+	}
+}
+
+// WithSampler 设置日志采样器，高频错误超过采样阈值后不再逐条记录日志，被丢弃的错误仍然计入监控，默认不采样。
+// 需要配合Handler.RunSampling使用，否则丢弃摘要要等到下一次错误或FlushSampling才会输出
+func WithSampler(s *Sampler) HandlerOption {
+	return func(h *Handler) {
+		h.sampler = s
+	}
+}
+
+// WithSLOTracker 设置SLO统计，Gin的ErrorMiddleware或RecoveryMiddleware、net/http的Recover
+// 按路由模板记录每个请求，默认不统计
+func WithSLOTracker(t *SLOTracker) HandlerOption {
+	return func(h *Handler) {
+		h.slo = t
+	}
+}
+
+// WithHTTPRoute 设置net/http请求的路由模板，用于SLO统计，默认为ServeMux匹配的Request.Pattern，
+// 使用chi等路由时可以返回其路由模板，返回空字符串的请求不计入统计
+func WithHTTPRoute(fn func(r *http.Request) string) HandlerOption {
+	return func(h *Handler) {
+		h.httpRoute = fn
+	}
+}
+
+// WithTrustedProxies 设置可信代理的网段，net/http的中间件只在直连地址属于可信代理时使用X-Forwarded-For
+// 中最右侧的不可信地址作为客户端地址，默认不信任任何代理，Gin的中间件使用Gin自身的可信代理配置
+func WithTrustedProxies(prefixes ...netip.Prefix) HandlerOption {
+	return func(h *Handler) {
+		h.trustedProxies = prefixes
+	}
+}
@@ -0,0 +1,273 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// PanicError 从panic中恢复的错误，保存panic的值、值的类型和panic发生处的goroutine堆栈
+type PanicError struct {
+	*ErrorImpl
+	// panic的值
+	value any
+	// panic发生处的goroutine堆栈
+	stack []byte
+}
+
+// NewPanicError 根据panic的值和recover处获取的debug.Stack()创建PanicError，
+// 堆栈中recover和runtime的帧会被去掉，从panic发生处开始
+func NewPanicError(value any, stack []byte) *PanicError {
+	err := newPanicError(value, stack)
+	fireCreate(err)
+	return err
+}
+
+// newPanicError 创建PanicError，不触发创建钩子，避免钩子自身panic时递归
+func newPanicError(value any, stack []byte) *PanicError {
+	impl := acquireError()
+	impl.code = ErrPanicRecovered.Code
+	impl.message = "panic recovered: " + panicMessage(value)
+	impl.publicMessage = ErrPanicRecovered.publicMessage()
+	impl.messageKey = ErrPanicRecovered.messageKey()
+	impl.httpStatus = ErrPanicRecovered.HttpStatus
+	impl.errType = ErrPanicRecovered.Type
+	impl.retryable = ErrPanicRecovered.Retryable
+	impl.severity = ErrPanicRecovered.Severity
+	impl.timestamp = time.Now().UTC()
+	if err, ok := value.(error); ok {
+		impl.cause = err
+	}
+
+	stack = trimPanicStack(stack)
+	impl.stackTrace = string(stack)
+	impl.WithMetadata("panic_type", fmt.Sprintf("%T", value))
+
+	return &PanicError{
+		ErrorImpl: impl,
+		value:     value,
+		stack:     stack,
+	}
+}
+
+// panicMessage 返回panic值的文本
+func panicMessage(value any) string {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case string:
+		return v
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// trimPanicStack 去掉堆栈中panic之前的帧，即recover处的帧和runtime内部的帧，
+// 找不到panic帧时原样返回
+func trimPanicStack(stack []byte) []byte {
+	lines := bytes.Split(bytes.TrimRight(stack, "\n"), []byte("\n"))
+	if len(lines) < 3 {
+		return stack
+	}
+
+	// 第一行是goroutine头，之后每两行是一个帧：函数和文件位置
+	idx := -1
+	for i := 1; i+1 < len(lines); i += 2 {
+		if bytes.HasPrefix(lines[i], []byte("panic(")) {
+			idx = i + 2
+			break
+		}
+	}
+	if idx < 0 {
+		return stack
+	}
+
+	// 跳过runtime.sigpanic等由运行时触发panic的帧
+	for idx+1 < len(lines) && bytes.HasPrefix(lines[idx], []byte("runtime.")) {
+		idx += 2
+	}
+
+	trimmed := make([][]byte, 0, len(lines)-idx+1)
+	trimmed = append(trimmed, lines[0])
+	trimmed = append(trimmed, lines[idx:]...)
+	return append(bytes.Join(trimmed, []byte("\n")), '\n')
+}
+
+// Value 返回panic的值
+func (e *PanicError) Value() any {
+	return e.value
+}
+
+// ValueType 返回panic值的Go类型
+func (e *PanicError) ValueType() string {
+	return fmt.Sprintf("%T", e.value)
+}
+
+// Stack 返回panic发生处的goroutine堆栈
+func (e *PanicError) Stack() []byte {
+	return e.stack
+}
+
+func (e *PanicError) Error() string {
+	return e.message
+}
+
+func (e *PanicError) WithMetadata(key string, val any) Error {
+	e.ErrorImpl.WithMetadata(key, val)
+	return e
+}
+
+func (e *PanicError) WithMetadataMap(metadata map[string]any) Error {
+	e.ErrorImpl.WithMetadataMap(metadata)
+	return e
+}
+
+// panicConfig panic处理的配置
+type panicConfig struct {
+	// 恢复后调用的处理函数
+	handler func(err *PanicError)
+	// 是否在处理后重新panic
+	repanic bool
+}
+
+type PanicOption func(c *panicConfig)
+
+// WithPanicHandler 设置恢复后调用的处理函数，覆盖SetPanicHandler设置的全局处理函数
+func WithPanicHandler(fn func(err *PanicError)) PanicOption {
+	return func(c *panicConfig) {
+		c.handler = fn
+	}
+}
+
+// WithRepanic 设置处理后是否重新panic，测试中可以用来暴露问题
+func WithRepanic(repanic bool) PanicOption {
+	return func(c *panicConfig) {
+		c.repanic = repanic
+	}
+}
+
+var (
+	// globalPanicHandler 全局的panic处理函数
+	globalPanicHandler atomic.Pointer[func(err *PanicError)]
+	// globalRepanic 全局的重新panic开关
+	globalRepanic atomic.Bool
+)
+
+// SetPanicHandler 设置SafeGo和Recover使用的全局处理函数，返回恢复之前设置的函数
+func SetPanicHandler(fn func(err *PanicError)) (restore func()) {
+	var prev *func(err *PanicError)
+	if fn == nil {
+		prev = globalPanicHandler.Swap(nil)
+	} else {
+		prev = globalPanicHandler.Swap(&fn)
+	}
+
+	return func() {
+		globalPanicHandler.Store(prev)
+	}
+}
+
+// SetRepanic 设置SafeGo和Recover的全局重新panic开关，返回恢复之前设置的函数
+func SetRepanic(repanic bool) (restore func()) {
+	prev := globalRepanic.Swap(repanic)
+	return func() {
+		globalRepanic.Store(prev)
+	}
+}
+
+// newPanicConfig 根据全局设置和选项创建配置
+func newPanicConfig(opts []PanicOption) panicConfig {
+	c := panicConfig{repanic: globalRepanic.Load()}
+	if fn := globalPanicHandler.Load(); fn != nil {
+		c.handler = *fn
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+// handlePanic 创建PanicError并调用处理函数，没有设置处理函数时调用fallback，
+// 开启重新panic时以PanicError重新panic
+func handlePanic(value any, opts []PanicOption, fallback func(err *PanicError)) *PanicError {
+	c := newPanicConfig(opts)
+	err := NewPanicError(value, debug.Stack())
+	if c.handler == nil {
+		c.handler = fallback
+	}
+	if c.handler != nil {
+		c.handler(err)
+	}
+
+	if c.repanic {
+		panic(err)
+	}
+
+	return err
+}
+
+// DefaultPanicHandler SafeGo没有设置处理函数时使用的处理函数，使用zap的全局日志以Error级别记录PanicError，
+// 全局日志没有启用Error级别时（未调用zap.ReplaceGlobals时为Nop）将脱敏后的panic信息和堆栈输出到标准错误
+func DefaultPanicHandler(err *PanicError) {
+	if l := zap.L(); l.Core().Enabled(zapcore.ErrorLevel) {
+		l.Error("panic recovered in goroutine", ZapError(err))
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "panic recovered in goroutine: %s\n%s",
+		DefaultRedactor().RedactString(panicMessage(err.Value())), err.Stack())
+}
+
+// SafeGo 在新的goroutine中执行fn，fn的panic会被恢复为PanicError并交给处理函数，
+// 没有设置处理函数时使用DefaultPanicHandler记录，避免goroutine的panic被静默丢弃
+func SafeGo(fn func(), opts ...PanicOption) {
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				handlePanic(r, opts, DefaultPanicHandler)
+			}
+		}()
+
+		fn()
+	}()
+}
+
+// Recover 在返回error的函数中通过defer使用，将panic恢复为PanicError并赋值给errp
+//
+//	func do() (err error) {
+//		defer errors.Recover(&err)
+//		...
+//	}
+func Recover(errp *error, opts ...PanicOption) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	err := handlePanic(r, opts, nil)
+	if errp != nil {
+		*errp = err
+	}
+}
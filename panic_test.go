@@ -0,0 +1,172 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+//go:noinline
+func panicSite(value any) {
+	panic(value)
+}
+
+func recoverFrom(value any, opts ...PanicOption) (err error) {
+	defer Recover(&err, opts...)
+	panicSite(value)
+	return nil
+}
+
+// TestRecover 测试Recover恢复panic并保留值、类型和panic处的堆栈
+func TestRecover(t *testing.T) {
+	err := recoverFrom("boom")
+
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Fatalf("err = %T, want *PanicError", err)
+	}
+	if pe.Value() != "boom" || pe.ValueType() != "string" {
+		t.Errorf("value = %v, type = %s", pe.Value(), pe.ValueType())
+	}
+	if pe.Code() != ErrPanicRecovered.Code || pe.Error() != "panic recovered: boom" {
+		t.Errorf("code = %s, error = %q", pe.Code(), pe.Error())
+	}
+	if pe.Metadata()["panic_type"] != "string" {
+		t.Errorf("metadata = %v", pe.Metadata())
+	}
+
+	stack := string(pe.Stack())
+	if !strings.HasPrefix(stack, "goroutine ") {
+		t.Errorf("堆栈应该保留goroutine头: %s", stack)
+	}
+	lines := strings.Split(stack, "\n")
+	if len(lines) < 2 || !strings.Contains(lines[1], "panicSite") {
+		t.Errorf("堆栈应该从panic处开始: %s", stack)
+	}
+	if strings.Contains(stack, "runtime/debug.Stack") || strings.Contains(stack, "errors.Recover") {
+		t.Errorf("堆栈不应该包含recover处的帧: %s", stack)
+	}
+	if pe.StackTrace() != stack {
+		t.Error("StackTrace() 应该返回panic处的堆栈")
+	}
+
+	// panic的值是error时保留错误链
+	err = recoverFrom(io.EOF)
+	if !errors.Is(err, io.EOF) || err.Error() != "panic recovered: EOF" {
+		t.Errorf("err = %v", err)
+	}
+
+	// 运行时错误跳过runtime的帧
+	err = func() (err error) {
+		defer Recover(&err)
+		var m map[string]int
+		m["x"] = 1
+		return nil
+	}()
+	errors.As(err, &pe)
+	if lines := strings.Split(string(pe.Stack()), "\n"); strings.HasPrefix(lines[1], "runtime.") {
+		t.Errorf("堆栈不应该从runtime开始: %s", pe.Stack())
+	}
+
+	// panic(nil)会被运行时转换为*runtime.PanicNilError
+	errors.As(recoverFrom(nil), &pe)
+	if pe.ValueType() != "*runtime.PanicNilError" {
+		t.Errorf("panic(nil) type = %s", pe.ValueType())
+	}
+}
+
+// TestRecover_Handler 测试处理函数和重新panic
+func TestRecover_Handler(t *testing.T) {
+	var handled *PanicError
+	restore := SetPanicHandler(func(err *PanicError) {
+		handled = err
+	})
+	defer restore()
+
+	err := recoverFrom(42)
+	if handled == nil || handled != err {
+		t.Fatalf("全局处理函数应该收到PanicError, handled = %v", handled)
+	}
+
+	var local *PanicError
+	_ = recoverFrom(43, WithPanicHandler(func(err *PanicError) { local = err }))
+	if local == nil || local.Value() != 43 || handled.Value() != 42 {
+		t.Error("选项中的处理函数应该覆盖全局处理函数")
+	}
+
+	defer SetRepanic(true)()
+	defer func() {
+		r := recover()
+		pe, ok := r.(*PanicError)
+		if !ok || pe.Value() != "again" {
+			t.Errorf("recover() = %v, want *PanicError", r)
+		}
+	}()
+	_ = recoverFrom("again")
+	t.Error("开启重新panic时不应该执行到这里")
+}
+
+// TestSafeGo 测试goroutine中的panic被恢复并交给处理函数
+func TestSafeGo(t *testing.T) {
+	ch := make(chan *PanicError, 1)
+	SafeGo(func() {
+		panicSite("worker failed")
+	}, WithPanicHandler(func(err *PanicError) {
+		ch <- err
+	}))
+
+	select {
+	case err := <-ch:
+		if err.Value() != "worker failed" || !strings.Contains(string(err.Stack()), "panicSite") {
+			t.Errorf("err = %v, stack = %s", err, err.Stack())
+		}
+	case <-time.After(time.Second):
+		t.Fatal("处理函数没有被调用")
+	}
+}
+
+// TestSafeGo_DefaultHandler 测试没有设置处理函数时panic由zap全局日志记录
+func TestSafeGo_DefaultHandler(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	defer zap.ReplaceGlobals(zap.New(core))()
+
+	SafeGo(func() {
+		panicSite("worker failed")
+	})
+
+	deadline := time.Now().Add(time.Second)
+	for logs.Len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("日志条数 = %d, want 1", len(entries))
+	}
+	if entries[0].Message != "panic recovered in goroutine" {
+		t.Errorf("Message = %s", entries[0].Message)
+	}
+	if _, ok := entries[0].ContextMap()["error"]; !ok {
+		t.Errorf("日志中缺少error字段: %v", entries[0].ContextMap())
+	}
+}
@@ -32,8 +32,13 @@ func acquireError() *ErrorImpl {
 	obj := errorImplPool.Get().(*ErrorImpl)
 	obj.code = ""
 	obj.message = ""
+	obj.publicMessage = ""
+	obj.messageKey = ""
+	obj.detail = ""
 	obj.httpStatus = 0
 	obj.errType = ""
+	obj.retryable = RetryByType
+	obj.severity = SeverityByType
 	obj.timestamp = time.Time{}
 	obj.stackTrace = ""
 	obj.cause = nil
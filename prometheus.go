@@ -0,0 +1,179 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WritePrometheus 以Prometheus文本格式输出监控快照，包括错误计数、熔断器状态和各路由的SLO状态，
+// 设置了WithPrometheusNamespace时指标名带有对应的前缀
+func (m *Monitor) WritePrometheus(w io.Writer) error {
+	snapshot := m.Snapshot()
+	p := &promWriter{w: bufio.NewWriter(w)}
+	if m.namespace != "" {
+		p.prefix = m.namespace + "_"
+	}
+
+	p.family("errors_total", "counter", "Total number of recorded errors.")
+	p.sample("errors_total", nil, float64(snapshot.Total))
+
+	p.family("errors_by_code_total", "counter", "Number of recorded errors by code.")
+	for _, code := range slices.Sorted(maps.Keys(snapshot.ByCode)) {
+		p.sample("errors_by_code_total", []string{"code", code}, float64(snapshot.ByCode[code]))
+	}
+
+	p.family("errors_by_type_total", "counter", "Number of recorded errors by type.")
+	for _, t := range slices.Sorted(maps.Keys(snapshot.ByType)) {
+		p.sample("errors_by_type_total", []string{"type", t.String()}, float64(snapshot.ByType[t]))
+	}
+
+	p.family("errors_by_status_total", "counter", "Number of recorded errors by HTTP status.")
+	for _, status := range slices.Sorted(maps.Keys(snapshot.ByStatus)) {
+		p.sample("errors_by_status_total", []string{"status", strconv.Itoa(status)}, float64(snapshot.ByStatus[status]))
+	}
+
+	p.family("errors_by_severity_total", "counter", "Number of recorded errors by severity.")
+	for _, severity := range slices.Sorted(maps.Keys(snapshot.BySeverity)) {
+		p.sample("errors_by_severity_total", []string{"severity", severity.String()}, float64(snapshot.BySeverity[severity]))
+	}
+
+	p.family("errors_log_dropped_total", "counter", "Number of errors whose log line was dropped by sampling.")
+	p.sample("errors_log_dropped_total", nil, float64(snapshot.Dropped))
+
+	if len(snapshot.Breakers) > 0 {
+		p.family("circuit_breaker_state", "gauge", "Circuit breaker state, 1 for the current state.")
+		for _, b := range snapshot.Breakers {
+			for _, state := range []BreakerState{BreakerClosed, BreakerOpen, BreakerHalfOpen} {
+				p.sample("circuit_breaker_state", []string{"name", b.Name, "state", state.String()}, boolValue(b.State == state.String()))
+			}
+		}
+	}
+
+	if len(snapshot.SLOs) > 0 {
+		p.family("slo_objective", "gauge", "Target success ratio of the route.")
+		for _, s := range snapshot.SLOs {
+			p.sample("slo_objective", []string{"route", s.Route}, s.Objective)
+		}
+		p.family("slo_requests", "gauge", "Number of requests in the SLO period.")
+		for _, s := range snapshot.SLOs {
+			p.sample("slo_requests", []string{"route", s.Route}, float64(s.Total))
+		}
+		p.family("slo_bad_requests", "gauge", "Number of requests consuming error budget in the SLO period.")
+		for _, s := range snapshot.SLOs {
+			p.sample("slo_bad_requests", []string{"route", s.Route}, float64(s.Bad))
+		}
+		p.family("slo_error_budget_remaining", "gauge", "Remaining error budget ratio, negative when overspent.")
+		for _, s := range snapshot.SLOs {
+			p.sample("slo_error_budget_remaining", []string{"route", s.Route}, s.BudgetRemaining)
+		}
+		p.family("slo_burn_rate", "gauge", "Error budget burn rate over the window.")
+		for _, s := range snapshot.SLOs {
+			// 不同组的窗口可能重复，如6h既是短窗口也是长窗口，同一窗口只输出一次
+			rates := make(map[time.Duration]float64)
+			for _, rate := range s.BurnRates {
+				rates[rate.Long], rates[rate.Short] = rate.LongRate, rate.ShortRate
+			}
+			for _, window := range slices.Sorted(maps.Keys(rates)) {
+				p.sample("slo_burn_rate", []string{"route", s.Route, "window", window.String()}, rates[window])
+			}
+		}
+		p.family("slo_burn_rate_alerting", "gauge", "Whether both windows of a burn rate pair exceed the factor.")
+		for _, s := range snapshot.SLOs {
+			for _, rate := range s.BurnRates {
+				window := rate.Long.String() + "/" + rate.Short.String()
+				p.sample("slo_burn_rate_alerting", []string{"route", s.Route, "window", window}, boolValue(rate.Alerting))
+			}
+		}
+	}
+
+	return p.flush()
+}
+
+// PrometheusHandler 返回输出Prometheus文本格式监控数据的http.Handler
+func (m *Monitor) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = m.WritePrometheus(w)
+	})
+}
+
+// promWriter Prometheus文本格式的输出，记录第一个写入错误
+type promWriter struct {
+	w *bufio.Writer
+	// 指标名前缀
+	prefix string
+	err    error
+}
+
+// family 输出指标的HELP和TYPE行
+func (p *promWriter) family(name, typ, help string) {
+	name = p.prefix + name
+	p.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// sample 输出一个样本，labels为交替的标签名和标签值
+func (p *promWriter) sample(name string, labels []string, value float64) {
+	var sb strings.Builder
+	sb.WriteString(p.prefix)
+	sb.WriteString(name)
+	if len(labels) > 0 {
+		sb.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(labels[i])
+			sb.WriteString(`="`)
+			sb.WriteString(promLabelEscaper.Replace(labels[i+1]))
+			sb.WriteByte('"')
+		}
+		sb.WriteByte('}')
+	}
+
+	p.printf("%s %s\n", sb.String(), strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+func (p *promWriter) printf(format string, args ...any) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
+
+func (p *promWriter) flush() error {
+	if p.err != nil {
+		return p.err
+	}
+
+	return p.w.Flush()
+}
+
+var promLabelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
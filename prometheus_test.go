@@ -0,0 +1,72 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestMonitor_PrometheusHandler 测试Prometheus输出的标签转义和熔断器状态
+func TestMonitor_PrometheusHandler(t *testing.T) {
+	m := NewMonitor()
+	m.Record(FastNew(&ErrCode{Code: `A"B\C`, HttpStatus: http.StatusTeapot, Type: ErrTypeBusiness}))
+	m.RegisterBreaker(NewCircuitBreaker(BreakerConfig{Name: "db"}))
+
+	rec := httptest.NewRecorder()
+	m.PrometheusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") {
+		t.Errorf("Content-Type = %s", rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		`errors_by_code_total{code="A\"B\\C"} 1`,
+		`circuit_breaker_state{name="db",state="closed"} 1`,
+		`circuit_breaker_state{name="db",state="open"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "slo_") {
+		t.Error("没有注册SLO统计时不应该输出SLO指标")
+	}
+}
+
+// TestMonitor_PrometheusNamespace 测试指标名前缀
+func TestMonitor_PrometheusNamespace(t *testing.T) {
+	m := NewMonitor(WithPrometheusNamespace("app"))
+	m.Record(FastNew(ErrTimeout))
+
+	var sb strings.Builder
+	if err := m.WritePrometheus(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# HELP app_errors_total Total number of recorded errors.\n# TYPE app_errors_total counter\napp_errors_total 1\n",
+		`app_errors_by_code_total{code="TIMEOUT"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "\nerrors_total") {
+		t.Errorf("存在没有前缀的指标:\n%s", out)
+	}
+}
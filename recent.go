@@ -0,0 +1,422 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"maps"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecentEntry 最近错误列表中的一条记录
+type RecentEntry struct {
+	// 自增ID，用于查看详情
+	ID uint64 `json:"id"`
+	// 记录时间
+	Time time.Time `json:"time"`
+	// 错误码
+	Code string `json:"code"`
+	// 错误类型
+	Type ErrType `json:"type"`
+	// HTTP状态码
+	Status int `json:"status"`
+	// 严重程度
+	Severity Severity `json:"severity"`
+	// 错误信息，已脱敏
+	Message string `json:"message"`
+	// 请求方法，通过中间件记录时存在
+	Method string `json:"method,omitempty"`
+	// 请求路径，通过中间件记录时存在
+	Path string `json:"path,omitempty"`
+	// 请求ID，通过中间件记录时存在
+	RequestID string `json:"requestId,omitempty"`
+	// 链路ID，通过中间件记录时存在
+	TraceID string `json:"traceId,omitempty"`
+}
+
+// RecentDetail 最近错误的详情，包含脱敏后的堆栈和元数据
+type RecentDetail struct {
+	RecentEntry
+	// 内部详情
+	Detail string `json:"detail,omitempty"`
+	// 原始错误
+	Cause string `json:"cause,omitempty"`
+	// 堆栈信息
+	Stack string `json:"stack,omitempty"`
+	// 元数据
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// RecentFilter 最近错误的过滤条件，零值字段不参与过滤
+type RecentFilter struct {
+	Code   string
+	Type   ErrType
+	Status int
+	Since  time.Time
+	Until  time.Time
+	// 最多返回的条数
+	Limit int
+}
+
+// match 判断记录是否满足过滤条件
+func (f RecentFilter) match(e RecentEntry) bool {
+	return (f.Code == "" || e.Code == f.Code) &&
+		(f.Type == "" || e.Type == f.Type) &&
+		(f.Status == 0 || e.Status == f.Status) &&
+		(f.Since.IsZero() || !e.Time.Before(f.Since)) &&
+		(f.Until.IsZero() || !e.Time.After(f.Until))
+}
+
+// RecentErrors 固定容量的最近错误环形缓冲区，可以通过创建钩子或中间件写入，并发安全
+// 写入时保存脱敏后的快照，不持有错误实例，写入之后再补充的元数据不会出现在记录中
+type RecentErrors struct {
+	mu sync.RWMutex
+	// 环形缓冲区
+	records []RecentDetail
+	// 下一个写入位置
+	next int
+	// 已写入的记录数，不超过容量
+	size int
+	// 自增ID
+	seq uint64
+	// 时钟
+	clock Clock
+	// 脱敏器，写入时作用于消息、详情、堆栈、原始错误和元数据
+	redactor *Redactor
+}
+
+type RecentOption func(r *RecentErrors)
+
+// WithRecentClock 设置时钟，默认为系统时钟
+func WithRecentClock(clock Clock) RecentOption {
+	return func(r *RecentErrors) {
+		r.clock = clock
+	}
+}
+
+// WithRecentRedactor 设置脱敏器，默认为DefaultRedactor，传入nil时关闭脱敏
+func WithRecentRedactor(redactor *Redactor) RecentOption {
+	return func(r *RecentErrors) {
+		r.redactor = redactor
+	}
+}
+
+// NewRecentErrors 创建最近错误缓冲区，capacity小于等于0时使用100
+func NewRecentErrors(capacity int, opts ...RecentOption) *RecentErrors {
+	if capacity <= 0 {
+		capacity = 100
+	}
+
+	r := &RecentErrors{
+		records:  make([]RecentDetail, capacity),
+		clock:    SystemClock{},
+		redactor: DefaultRedactor(),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// Observe 注册错误创建钩子，之后创建的每个Error都会被记录，返回移除钩子的函数
+func (r *RecentErrors) Observe() (remove func()) {
+	return OnCreate(func(err Error) {
+		r.Add(err)
+	})
+}
+
+// Add 记录一个错误，nil会被忽略
+func (r *RecentErrors) Add(err error) {
+	r.add(err, requestInfo{})
+}
+
+// add 记录错误以及请求信息，在加锁前生成脱敏后的快照
+func (r *RecentErrors) add(err error, info requestInfo) {
+	if r == nil || err == nil {
+		return
+	}
+
+	e := asError(err)
+	detail := RecentDetail{
+		RecentEntry: RecentEntry{
+			Time:      r.clock.Now(),
+			Code:      e.Code(),
+			Type:      e.Type(),
+			Status:    e.HttpStatus(),
+			Severity:  SeverityOf(e),
+			Message:   r.redactor.RedactString(e.Message()),
+			Method:    info.method,
+			Path:      info.path,
+			RequestID: info.requestID,
+			TraceID:   info.traceID,
+		},
+		Detail: r.redactor.RedactString(e.Detail()),
+		Stack:  r.redactor.RedactString(e.StackTrace()),
+		// 关闭脱敏时RedactMetadata返回原map，需要复制
+		Metadata: maps.Clone(r.redactor.RedactMetadata(e.Metadata())),
+	}
+	if cause := e.Unwrap(); cause != nil {
+		detail.Cause = r.redactor.RedactString(cause.Error())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	detail.ID = r.seq
+	r.records[r.next] = detail
+	r.next = (r.next + 1) % len(r.records)
+	r.size = min(r.size+1, len(r.records))
+}
+
+// Len 返回当前记录数
+func (r *RecentErrors) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.size
+}
+
+// List 返回满足过滤条件的记录，从新到旧排列
+func (r *RecentErrors) List(filter RecentFilter) []RecentEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entries := make([]RecentEntry, 0, r.size)
+	for i := range r.size {
+		record := r.records[(r.next-1-i+len(r.records))%len(r.records)]
+		if !filter.match(record.RecentEntry) {
+			continue
+		}
+
+		entries = append(entries, record.RecentEntry)
+		if filter.Limit > 0 && len(entries) >= filter.Limit {
+			break
+		}
+	}
+
+	return entries
+}
+
+// Get 返回指定ID的详情，记录已被覆盖时返回false
+func (r *RecentErrors) Get(id uint64) (RecentDetail, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id == 0 || id > r.seq || r.seq-id >= uint64(r.size) {
+		return RecentDetail{}, false
+	}
+
+	// 第seq条记录位于next-1，往前推算目标记录的位置
+	offset := int(r.seq - id)
+	detail := r.records[(r.next-1-offset+len(r.records))%len(r.records)]
+	// 返回副本，调用方修改不影响缓冲区中的快照
+	detail.Metadata = maps.Clone(detail.Metadata)
+
+	return detail, true
+}
+
+// Handler 返回查看最近错误的管理接口，需要挂载在独立的路径前缀下并配合http.StripPrefix使用
+//   - GET /          列表，支持code、type、status、since、until、limit查询参数
+//   - GET /{id}      详情，包含脱敏后的堆栈和元数据
+//
+// since和until可以是RFC 3339时间或相对当前的时长（如15m），format=html或Accept包含
+// text/html时返回HTML页面，否则返回JSON。HTML页面中的链接根据原始请求路径生成，
+// 前缀是否带末尾的/都可以访问。
+// 接口本身没有任何鉴权，详情中包含堆栈和元数据，必须挂载在鉴权中间件之后，不要直接暴露到公网
+func (r *RecentErrors) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			writeRecentJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+
+		html := wantsHTML(req)
+		if id := strings.Trim(req.URL.Path, "/"); id != "" {
+			r.serveDetail(w, req, id, html)
+			return
+		}
+
+		filter, err := parseRecentFilter(req, r.clock.Now())
+		if err != nil {
+			writeRecentJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+
+		entries := r.List(filter)
+		if html {
+			renderRecentHTML(w, http.StatusOK, recentListTemplate, recentPage{
+				Base: strings.TrimSuffix(originalPath(req), "/") + "/",
+				Data: entries,
+			})
+			return
+		}
+		writeRecentJSON(w, http.StatusOK, map[string]any{"total": len(entries), "errors": entries})
+	})
+}
+
+// serveDetail 输出单条记录的详情
+func (r *RecentErrors) serveDetail(w http.ResponseWriter, req *http.Request, rawID string, html bool) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		writeRecentJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+
+	detail, ok := r.Get(id)
+	if !ok {
+		writeRecentJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+
+	if html {
+		path := strings.TrimSuffix(originalPath(req), "/")
+		renderRecentHTML(w, http.StatusOK, recentDetailTemplate, recentPage{
+			Base: path[:strings.LastIndex(path, "/")+1],
+			Data: detail,
+		})
+		return
+	}
+	writeRecentJSON(w, http.StatusOK, detail)
+}
+
+// parseRecentFilter 解析查询参数中的过滤条件
+// 返回普通错误而不是Error，避免触发创建钩子，使Observe的缓冲区记录管理接口自身的参数错误
+func parseRecentFilter(req *http.Request, now time.Time) (RecentFilter, error) {
+	q := req.URL.Query()
+	filter := RecentFilter{
+		Code: q.Get("code"),
+		Type: ErrType(strings.ToUpper(q.Get("type"))),
+	}
+
+	var err error
+	if v := q.Get("status"); v != "" {
+		if filter.Status, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("invalid status %q", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, fmt.Errorf("invalid limit %q", v)
+		}
+	}
+	if filter.Since, err = parseRecentTime(q.Get("since"), now); err != nil {
+		return filter, err
+	}
+	if filter.Until, err = parseRecentTime(q.Get("until"), now); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+// parseRecentTime 解析RFC 3339时间或相对当前的时长
+func parseRecentTime(v string, now time.Time) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d.Abs()), nil
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q", v)
+}
+
+// recentPage HTML页面的数据，Base为列表页的绝对路径，以/结尾
+type recentPage struct {
+	Base string
+	Data any
+}
+
+// originalPath 返回StripPrefix之前的请求路径，RequestURI为空时使用URL.Path
+func originalPath(req *http.Request) string {
+	if req.RequestURI != "" {
+		if u, err := url.ParseRequestURI(req.RequestURI); err == nil && u.Path != "" {
+			return u.Path
+		}
+	}
+
+	return req.URL.Path
+}
+
+// wantsHTML 判断客户端是否需要HTML页面
+func wantsHTML(req *http.Request) bool {
+	if format := req.URL.Query().Get("format"); format != "" {
+		return format == "html"
+	}
+
+	return strings.Contains(req.Header.Get("Accept"), "text/html")
+}
+
+// writeRecentJSON 输出JSON响应
+func writeRecentJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// renderRecentHTML 输出HTML页面
+func renderRecentHTML(w http.ResponseWriter, status int, tmpl *template.Template, data any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = tmpl.Execute(w, data)
+}
+
+var recentListTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Recent errors</title>
+<style>body{font-family:sans-serif}table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:4px 8px;text-align:left}</style>
+</head><body>
+<h1>Recent errors ({{len .Data}})</h1>
+<table>
+<tr><th>ID</th><th>Time</th><th>Code</th><th>Type</th><th>Status</th><th>Severity</th><th>Request</th><th>Message</th></tr>
+{{range .Data}}<tr><td><a href="{{$.Base}}{{.ID}}?format=html">{{.ID}}</a></td><td>{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}</td><td>{{.Code}}</td><td>{{.Type}}</td><td>{{.Status}}</td><td>{{.Severity}}</td><td>{{.Method}} {{.Path}}</td><td>{{.Message}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+var recentDetailTemplate = template.Must(template.New("detail").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Error {{.Data.ID}}</title>
+<style>body{font-family:sans-serif}pre{background:#f5f5f5;padding:8px}</style>
+</head><body>
+<h1>{{.Data.Code}} #{{.Data.ID}}</h1>
+<p><a href="{{.Base}}?format=html">Back</a></p>
+{{with .Data}}<dl>
+<dt>Time</dt><dd>{{.Time.Format "2006-01-02T15:04:05.000Z07:00"}}</dd>
+<dt>Type</dt><dd>{{.Type}}</dd>
+<dt>Status</dt><dd>{{.Status}}</dd>
+<dt>Severity</dt><dd>{{.Severity}}</dd>
+<dt>Message</dt><dd>{{.Message}}</dd>
+{{if .Path}}<dt>Request</dt><dd>{{.Method}} {{.Path}} {{.RequestID}}</dd>{{end}}
+{{if .Detail}}<dt>Detail</dt><dd>{{.Detail}}</dd>{{end}}
+{{if .Cause}}<dt>Cause</dt><dd>{{.Cause}}</dd>{{end}}
+</dl>
+{{if .Metadata}}<h2>Metadata</h2><table>{{range $k, $v := .Metadata}}<tr><td>{{$k}}</td><td>{{$v}}</td></tr>{{end}}</table>{{end}}
+{{if .Stack}}<h2>Stack</h2><pre>{{.Stack}}</pre>{{end}}{{end}}
+</body></html>
+`))
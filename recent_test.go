@@ -0,0 +1,223 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestRecentErrors 测试环形缓冲区的覆盖、过滤和详情
+func TestRecentErrors(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	r := NewRecentErrors(3, WithRecentClock(clock))
+
+	for i := range 4 {
+		r.Add(loadUser(i))
+		clock.Advance(time.Minute)
+	}
+	r.Add(nil)
+	if r.Len() != 3 {
+		t.Fatalf("Len() = %d", r.Len())
+	}
+
+	entries := r.List(RecentFilter{})
+	if len(entries) != 3 || entries[0].ID != 4 || entries[2].ID != 2 {
+		t.Fatalf("List() = %+v", entries)
+	}
+	if entries[0].Message != "user 3 not found" || entries[0].Status != http.StatusNotFound {
+		t.Errorf("entry = %+v", entries[0])
+	}
+
+	if _, ok := r.Get(1); ok {
+		t.Error("被覆盖的记录不应该能查到")
+	}
+	if _, ok := r.Get(5); ok {
+		t.Error("不存在的记录不应该能查到")
+	}
+	detail, ok := r.Get(2)
+	if !ok || detail.Message != "user 1 not found" || detail.Stack == "" {
+		t.Errorf("Get(2) = %+v, %v", detail, ok)
+	}
+
+	filtered := r.List(RecentFilter{Since: start.Add(2 * time.Minute), Limit: 1})
+	if len(filtered) != 1 || filtered[0].ID != 4 {
+		t.Errorf("filtered = %+v", filtered)
+	}
+	if got := r.List(RecentFilter{Code: ErrTimeout.Code}); len(got) != 0 {
+		t.Errorf("按错误码过滤 = %+v", got)
+	}
+}
+
+// TestRecentErrorsObserve 测试通过创建钩子记录错误，记录的是写入时脱敏后的快照
+func TestRecentErrorsObserve(t *testing.T) {
+	defer IsolateHooks()()
+
+	r := NewRecentErrors(10)
+	remove := r.Observe()
+	err := FastNew(ErrInternal).WithMetadata("later", "x")
+	remove()
+	FastNew(ErrInternal)
+
+	if r.Len() != 1 {
+		t.Fatalf("Len() = %d", r.Len())
+	}
+	detail, _ := r.Get(1)
+	if detail.Code != err.Code() || detail.Severity != SeverityError {
+		t.Errorf("detail = %+v", detail)
+	}
+	if _, ok := detail.Metadata["later"]; ok {
+		t.Errorf("写入之后补充的元数据不应该出现在快照中: %v", detail.Metadata)
+	}
+
+	r.Add(FastNew(ErrInternal).WithMetadata("password", "secret").WithMetadata("email", "alice@example.com"))
+	detail, _ = r.Get(2)
+	if detail.Metadata["password"] == "secret" || strings.Contains(detail.Metadata["email"].(string), "alice") {
+		t.Errorf("元数据没有脱敏: %v", detail.Metadata)
+	}
+}
+
+// TestRecentErrorsConcurrentMetadata 测试记录后继续修改错误的元数据与读取详情之间没有数据竞争
+func TestRecentErrorsConcurrentMetadata(t *testing.T) {
+	r := NewRecentErrors(10)
+	err := FastNew(ErrInternal).WithMetadata("user_id", 1)
+	r.Add(err)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := range 1000 {
+			err.WithMetadata("attempt", i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 1000 {
+			detail, ok := r.Get(1)
+			if !ok {
+				t.Error("记录不存在")
+				return
+			}
+			detail.Metadata["mutated"] = true
+			_ = r.List(RecentFilter{})
+		}
+	}()
+	wg.Wait()
+
+	detail, _ := r.Get(1)
+	if len(detail.Metadata) != 1 || detail.Metadata["user_id"] != 1 {
+		t.Errorf("快照元数据 = %v, want 只包含user_id", detail.Metadata)
+	}
+}
+
+// TestRecentErrorsHandler 测试管理接口的JSON、HTML和详情
+func TestRecentErrorsHandler(t *testing.T) {
+	r := NewRecentErrors(10)
+	h := NewHandler(nil, WithRecentErrors(r))
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil), FastNew(ErrNotFound))
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/pay", nil), FastNew(ErrTimeout))
+
+	admin := httptest.NewServer(http.StripPrefix("/errors", r.Handler()))
+	defer admin.Close()
+
+	get := func(path string, accept string) (*http.Response, string) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, admin.URL+path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	resp, body := get("/errors/?type=timeout", "")
+	var list struct {
+		Total  int           `json:"total"`
+		Errors []RecentEntry `json:"errors"`
+	}
+	if err := json.Unmarshal([]byte(body), &list); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || list.Total != 1 || list.Errors[0].Path != "/pay" || list.Errors[0].Method != http.MethodPost {
+		t.Errorf("list = %s", body)
+	}
+
+	resp, body = get("/errors/?status=404&since=1h", "text/html")
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html") || !strings.Contains(body, "/users/1") || strings.Contains(body, "/pay") {
+		t.Errorf("html = %s", body)
+	}
+
+	for _, path := range []string{"/errors/?format=html", "/errors?format=html"} {
+		if _, body := get(path, ""); !strings.Contains(body, `href="/errors/1?format=html"`) {
+			t.Errorf("%s: 列表链接没有使用请求路径: %s", path, body)
+		}
+	}
+	if _, body := get("/errors/1?format=html", ""); !strings.Contains(body, `href="/errors/?format=html"`) {
+		t.Errorf("详情的返回链接没有使用请求路径: %s", body)
+	}
+
+	resp, body = get("/errors/1", "")
+	var detail RecentDetail
+	if err := json.Unmarshal([]byte(body), &detail); err != nil || resp.StatusCode != http.StatusOK || detail.Code != ErrNotFound.Code {
+		t.Errorf("detail = %s", body)
+	}
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/errors/99", http.StatusNotFound},
+		{"/errors/abc", http.StatusBadRequest},
+		{"/errors/?status=x", http.StatusBadRequest},
+		{"/errors/?since=yesterday", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if resp, _ := get(tt.path, ""); resp.StatusCode != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.path, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+// TestRecentErrorsHandlerBadQuery 测试管理接口的参数错误不会被Observe记录
+func TestRecentErrorsHandlerBadQuery(t *testing.T) {
+	defer IsolateHooks()()
+
+	r := NewRecentErrors(10)
+	defer r.Observe()()
+
+	for _, query := range []string{"limit=x", "status=x", "since=yesterday"} {
+		rec := httptest.NewRecorder()
+		r.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid") {
+			t.Errorf("%s: status = %d, body = %s", query, rec.Code, rec.Body.String())
+		}
+	}
+	if r.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", r.Len())
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// DefaultRedactMask 默认的脱敏替换文本
+const DefaultRedactMask = "[REDACTED]"
+
+// Detector 敏感信息检测器，匹配到的内容会被替换为[REDACTED:<Name>]
+type Detector struct {
+	// 检测器名称
+	Name string
+	// 匹配规则
+	Pattern *regexp.Regexp
+	// 可选的二次校验，返回false时保留原文，例如信用卡号的Luhn校验
+	Validate func(match string) bool
+}
+
+var (
+	// EmailDetector 邮箱检测器
+	EmailDetector = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	}
+	// CreditCardDetector 信用卡号检测器，使用Luhn算法过滤普通数字
+	CreditCardDetector = Detector{
+		Name:     "credit_card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: luhnValid,
+	}
+	// PhoneDetector 手机号检测器，支持中国大陆手机号和常见的国际格式
+	PhoneDetector = Detector{
+		Name:    "phone",
+		Pattern: regexp.MustCompile(`(?:\+\d{1,3}[ \-]?)?(?:\b1[3-9]\d{9}\b|\(?\b\d{3}\)?[ \-]\d{3}[ \-]\d{4}\b)`),
+	}
+	// BearerTokenDetector Bearer令牌检测器
+	BearerTokenDetector = Detector{
+		Name:    "bearer_token",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`),
+	}
+)
+
+// defaultSensitiveKeys 默认需要脱敏的元数据键名，匹配时忽略大小写、下划线和中划线，
+// 键名包含其中任意一个即脱敏，如user_password、apiKeyHeader
+var defaultSensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "access_token", "refresh_token",
+	"api_key", "authorization", "cookie", "dsn", "private_key", "credential",
+}
+
+// BuiltinDetectors 返回内置的敏感信息检测器，信用卡检测器排在手机号之前，避免卡号被截断匹配
+func BuiltinDetectors() []Detector {
+	return []Detector{EmailDetector, CreditCardDetector, PhoneDetector, BearerTokenDetector}
+}
+
+// Redactor 脱敏器，在错误离开进程（HTTP响应、日志、监控）之前清理元数据、错误信息和原始错误信息
+// 规则分为三类：按键名、按正则、按值类型。nil的Redactor不做任何处理
+type Redactor struct {
+	// 替换文本
+	mask string
+	// 需要脱敏的键名，已归一化
+	keys map[string]struct{}
+	// 正则检测器
+	detectors []Detector
+	// 需要脱敏的值类型
+	types map[reflect.Type]struct{}
+}
+
+type RedactorOption func(r *Redactor)
+
+// WithRedactMask 设置按键名和类型脱敏时的替换文本，默认为[REDACTED]
+func WithRedactMask(mask string) RedactorOption {
+	return func(r *Redactor) {
+		r.mask = mask
+	}
+}
+
+// WithRedactKeys 添加需要脱敏的元数据键名，匹配时忽略大小写、下划线和中划线，
+// 归一化后的键名包含任意一个敏感键名即脱敏，如password同时匹配user_password和PasswordHash
+func WithRedactKeys(keys ...string) RedactorOption {
+	return func(r *Redactor) {
+		for _, key := range keys {
+			if key = normalizeRedactKey(key); key != "" {
+				r.keys[key] = struct{}{}
+			}
+		}
+	}
+}
+
+// WithDetectors 添加正则检测器，作用于所有字符串内容
+func WithDetectors(detectors ...Detector) RedactorOption {
+	return func(r *Redactor) {
+		r.detectors = append(r.detectors, detectors...)
+	}
+}
+
+// WithRedactPattern 添加自定义的正则规则
+func WithRedactPattern(name string, pattern *regexp.Regexp) RedactorOption {
+	return WithDetectors(Detector{Name: name, Pattern: pattern})
+}
+
+// WithRedactTypes 添加需要整体脱敏的值类型，参数为该类型的样例值
+func WithRedactTypes(samples ...any) RedactorOption {
+	return func(r *Redactor) {
+		for _, sample := range samples {
+			r.types[reflect.TypeOf(sample)] = struct{}{}
+		}
+	}
+}
+
+// NewRedactor 创建不带任何规则的脱敏器
+func NewRedactor(opts ...RedactorOption) *Redactor {
+	r := &Redactor{
+		mask:  DefaultRedactMask,
+		keys:  make(map[string]struct{}),
+		types: make(map[reflect.Type]struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// DefaultRedactor 创建带默认敏感键名和内置检测器的脱敏器
+func DefaultRedactor(opts ...RedactorOption) *Redactor {
+	defaults := []RedactorOption{
+		WithRedactKeys(defaultSensitiveKeys...),
+		WithDetectors(BuiltinDetectors()...),
+	}
+
+	return NewRedactor(append(defaults, opts...)...)
+}
+
+// RedactString 使用正则检测器清理字符串
+func (r *Redactor) RedactString(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	for _, d := range r.detectors {
+		replacement := "[REDACTED:" + d.Name + "]"
+		s = d.Pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.Validate != nil && !d.Validate(match) {
+				return match
+			}
+			return replacement
+		})
+	}
+
+	return s
+}
+
+// RedactMetadata 返回脱敏后的元数据副本，不修改原始元数据
+func (r *Redactor) RedactMetadata(metadata map[string]any) map[string]any {
+	if r == nil || metadata == nil {
+		return metadata
+	}
+
+	redacted := make(map[string]any, len(metadata))
+	for k, v := range metadata {
+		redacted[k] = r.RedactValue(k, v)
+	}
+
+	return redacted
+}
+
+// RedactValue 按键名、值类型和正则检测器依次对单个值进行脱敏
+func (r *Redactor) RedactValue(key string, val any) any {
+	if r == nil || val == nil {
+		return val
+	}
+
+	if r.sensitiveKey(key) {
+		return r.mask
+	}
+
+	if _, ok := r.types[reflect.TypeOf(val)]; ok {
+		return r.mask
+	}
+
+	switch v := val.(type) {
+	case string:
+		return r.RedactString(v)
+	case error:
+		return r.RedactString(v.Error())
+	case map[string]any:
+		return r.RedactMetadata(v)
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for k, s := range v {
+			if r.sensitiveKey(k) {
+				redacted[k] = r.mask
+				continue
+			}
+			redacted[k] = r.RedactString(s)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = r.RedactString(s)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = r.RedactValue("", item)
+		}
+		return redacted
+	default:
+		return val
+	}
+}
+
+// sensitiveKey 归一化后的键名是否包含任意一个需要脱敏的键名
+func (r *Redactor) sensitiveKey(key string) bool {
+	if key == "" || len(r.keys) == 0 {
+		return false
+	}
+
+	key = normalizeRedactKey(key)
+	if _, ok := r.keys[key]; ok {
+		return true
+	}
+	for k := range r.keys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactKeyReplacer 归一化键名时移除下划线和中划线
+var redactKeyReplacer = strings.NewReplacer("_", "", "-", "")
+
+// normalizeRedactKey 归一化键名，忽略大小写、下划线和中划线
+func normalizeRedactKey(key string) string {
+	return redactKeyReplacer.Replace(strings.ToLower(key))
+}
+
+// luhnValid 使用Luhn算法校验卡号
+func luhnValid(number string) bool {
+	sum, count := 0, 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+		count++
+	}
+
+	return count >= 13 && sum%10 == 0
+}
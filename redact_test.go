@@ -0,0 +1,135 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// TestRedactor_RedactString 测试内置检测器
+func TestRedactor_RedactString(t *testing.T) {
+	r := DefaultRedactor()
+
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "邮箱",
+			input: "user alice@example.com exists",
+			want:  "user [REDACTED:email] exists",
+		},
+		{
+			name:  "中国大陆手机号",
+			input: "phone 13812345678 exists",
+			want:  "phone [REDACTED:phone] exists",
+		},
+		{
+			name:  "信用卡号",
+			input: "card 4111 1111 1111 1111 declined",
+			want:  "card [REDACTED:credit_card] declined",
+		},
+		{
+			name:  "不满足Luhn校验的数字",
+			input: "order 1234567890123 failed",
+			want:  "order 1234567890123 failed",
+		},
+		{
+			name:  "Bearer令牌",
+			input: "header Authorization: Bearer eyJhbGciOi.abc-123",
+			want:  "header Authorization: [REDACTED:bearer_token]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.RedactString(tt.input); got != tt.want {
+				t.Errorf("RedactString() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type secretValue struct{ v string }
+
+// TestRedactor_RedactMetadata 测试按键名、类型和正则对元数据脱敏
+func TestRedactor_RedactMetadata(t *testing.T) {
+	r := DefaultRedactor(
+		WithRedactKeys("id_card"),
+		WithRedactTypes(secretValue{}),
+		WithRedactPattern("sql", regexp.MustCompile(`(?i)select .+ from \w+`)),
+	)
+
+	metadata := map[string]any{
+		"Password":      "p@ss",
+		"API-Key":       "k",
+		"idCard":        "110101",
+		"secret":        secretValue{"x"},
+		"user_password": "p@ss",
+		"apiKeyHeader":  "k",
+		"query":         "SELECT * FROM users where id = 1",
+		"nested":        map[string]any{"token": "t", "email": "bob@example.com"},
+		"count":         3,
+	}
+
+	got := r.RedactMetadata(metadata)
+	for _, key := range []string{"Password", "API-Key", "idCard", "secret", "user_password", "apiKeyHeader"} {
+		if got[key] != DefaultRedactMask {
+			t.Errorf("metadata[%s] = %v, want %s", key, got[key], DefaultRedactMask)
+		}
+	}
+	if got["query"] != "[REDACTED:sql] where id = 1" {
+		t.Errorf("metadata[query] = %v", got["query"])
+	}
+	nested := got["nested"].(map[string]any)
+	if nested["token"] != DefaultRedactMask || nested["email"] != "[REDACTED:email]" {
+		t.Errorf("metadata[nested] = %v", nested)
+	}
+	if got["count"] != 3 {
+		t.Errorf("metadata[count] = %v, want 3", got["count"])
+	}
+
+	// 原始元数据不应该被修改
+	if metadata["Password"] != "p@ss" {
+		t.Error("RedactMetadata不应该修改原始元数据")
+	}
+}
+
+// TestHandler_Redaction 测试HTTP响应中的脱敏
+func TestHandler_Redaction(t *testing.T) {
+	h := NewHandler(nil, WithShowDetails())
+	err := Newf(ErrPhoneExisted, "phone %s already exists", "13812345678").
+		WithMetadata("token", "abc")
+
+	rec := httptest.NewRecorder()
+	h.WriteError(rec, httptest.NewRequest(http.MethodPost, "/users", nil), err)
+
+	body := rec.Body.String()
+	if strings.Contains(body, "13812345678") || strings.Contains(body, "abc") {
+		t.Errorf("响应中包含敏感信息: %s", body)
+	}
+
+	// 关闭脱敏
+	rec = httptest.NewRecorder()
+	NewHandler(nil, WithRedactor(nil)).WriteError(rec, httptest.NewRequest(http.MethodPost, "/users", nil), err)
+	if !strings.Contains(rec.Body.String(), "13812345678") {
+		t.Errorf("关闭脱敏后响应应该包含原始信息: %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"sync"
+)
+
+// 已注册的错误码，key为消息键，未设置MessageKey时为Code，
+// 因此Code相同但MessageKey不同的错误码可以同时注册
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]*ErrCode)
+	// 按注册顺序保存的错误码，按Code查找时返回最先注册的
+	registryOrder []*ErrCode
+)
+
+// Register 注册错误码，校验消息键（未设置MessageKey时为Code）唯一以及Message和PublicMessage模板
+// 只引用Params中声明的参数
+func Register(code *ErrCode) error {
+	if code == nil || code.Code == "" {
+		return fmt.Errorf("errors: register empty code")
+	}
+
+	if err := validateTemplate(code.Message, code.Params); err != nil {
+		return fmt.Errorf("errors: register %s: %w", code.Code, err)
+	}
+	if err := validateTemplate(code.PublicMessage, code.Params); err != nil {
+		return fmt.Errorf("errors: register %s: %w", code.Code, err)
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	key := code.messageKey()
+	if existed, ok := registry[key]; ok {
+		if existed != code {
+			return fmt.Errorf("errors: message key %s already registered", key)
+		}
+		return nil
+	}
+	registry[key] = code
+	registryOrder = append(registryOrder, code)
+
+	return nil
+}
+
+// MustRegister 注册错误码，校验失败时panic，便于在包级变量声明中使用
+func MustRegister(code *ErrCode) *ErrCode {
+	if err := Register(code); err != nil {
+		panic(err)
+	}
+
+	return code
+}
+
+// LookupCode 根据Code查找已注册的错误码，多个错误码共用同一个Code时，
+// 优先返回消息键等于Code的错误码，其次返回最先注册的
+func LookupCode(code string) (*ErrCode, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	if c, ok := registry[code]; ok && c.Code == code {
+		return c, true
+	}
+	for _, c := range registryOrder {
+		if c.Code == code {
+			return c, true
+		}
+	}
+
+	return nil, false
+}
+
+// lookupMessageKey 根据消息键查找已注册的错误码
+func lookupMessageKey(key string) (*ErrCode, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	c, ok := registry[key]
+	return c, ok
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+// Result 携带值或Error的结果，适用于通过channel传递的流水线
+// 错误统一转换为本包的Error，错误码和HTTP状态码在流水线中不会丢失
+type Result[T any] struct {
+	value T
+	err   Error
+}
+
+// Ok 创建成功的结果
+func Ok[T any](value T) Result[T] {
+	return Result[T]{value: value}
+}
+
+// Err 创建失败的结果，非Error类型的错误使用DefaultClassifier包装，err为nil时结果为零值的成功结果
+func Err[T any](err error) Result[T] {
+	return Result[T]{err: asError(err)}
+}
+
+// ResultOf 将(T, error)转换为结果，err不为nil时丢弃value
+func ResultOf[T any](value T, err error) Result[T] {
+	if err != nil {
+		return Err[T](err)
+	}
+
+	return Ok(value)
+}
+
+// IsOk 是否成功
+func (r Result[T]) IsOk() bool {
+	return r.err == nil
+}
+
+// IsErr 是否失败
+func (r Result[T]) IsErr() bool {
+	return r.err != nil
+}
+
+// Err 返回错误，成功时返回nil
+func (r Result[T]) Err() Error {
+	return r.err
+}
+
+// Unwrap 返回值和错误，失败时值为零值
+func (r Result[T]) Unwrap() (T, Error) {
+	return r.value, r.err
+}
+
+// UnwrapOr 成功时返回值，失败时返回def
+func (r Result[T]) UnwrapOr(def T) T {
+	if r.err != nil {
+		return def
+	}
+
+	return r.value
+}
+
+// Map 成功时使用fn转换值，失败时原样传递错误
+func Map[T, U any](r Result[T], fn func(T) U) Result[U] {
+	if r.err != nil {
+		return Result[U]{err: r.err}
+	}
+
+	return Ok(fn(r.value))
+}
+
+// AndThen 成功时执行返回结果的fn，失败时原样传递错误，fn不会被调用
+func AndThen[T, U any](r Result[T], fn func(T) Result[U]) Result[U] {
+	if r.err != nil {
+		return Result[U]{err: r.err}
+	}
+
+	return fn(r.value)
+}
+
+// Collect 收集所有成功的值，并将所有失败聚合为AggregateWorst策略的MultiError，没有失败时错误为nil
+func Collect[T any](results []Result[T]) ([]T, Error) {
+	values := make([]T, 0, len(results))
+	errs := NewMultiError(AggregateWorst)
+	for _, r := range results {
+		values, errs = collectResult(values, errs, r)
+	}
+
+	return values, errs.ErrorOrNil()
+}
+
+// CollectChan 从channel中读取结果直到channel关闭，收集方式与Collect相同
+func CollectChan[T any](ch <-chan Result[T]) ([]T, Error) {
+	var values []T
+	errs := NewMultiError(AggregateWorst)
+	for r := range ch {
+		values, errs = collectResult(values, errs, r)
+	}
+
+	return values, errs.ErrorOrNil()
+}
+
+// collectResult 将单个结果加入值列表或错误聚合
+func collectResult[T any](values []T, errs *MultiError, r Result[T]) ([]T, *MultiError) {
+	if r.err != nil {
+		return values, errs.Append(r.err)
+	}
+
+	return append(values, r.value), errs
+}
@@ -0,0 +1,89 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"database/sql"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+// TestResult 测试结果的构造和转换
+func TestResult(t *testing.T) {
+	ok := Ok(21)
+	if !ok.IsOk() || ok.IsErr() || ok.UnwrapOr(0) != 21 {
+		t.Errorf("Ok(21) = %+v", ok)
+	}
+
+	doubled := Map(ok, func(v int) int { return v * 2 })
+	if v, err := doubled.Unwrap(); v != 42 || err != nil {
+		t.Errorf("Map() = %v, %v", v, err)
+	}
+
+	parsed := AndThen(Ok("x"), func(s string) Result[int] {
+		return ResultOf(strconv.Atoi(s))
+	})
+	if !parsed.IsErr() || parsed.Err().Code() != ErrInternal.Code || parsed.UnwrapOr(-1) != -1 {
+		t.Errorf("AndThen() = %+v", parsed)
+	}
+
+	failed := Err[int](sql.ErrNoRows)
+	if failed.Err().Code() != ErrNotFound.Code {
+		t.Errorf("Err() code = %s, want %s", failed.Err().Code(), ErrNotFound.Code)
+	}
+
+	called := false
+	mapped := Map(failed, func(v int) string {
+		called = true
+		return strconv.Itoa(v)
+	})
+	if called || mapped.Err() != failed.Err() {
+		t.Error("失败的结果不应该调用fn，且应该保留原始错误")
+	}
+
+	if r := Err[int](nil); !r.IsOk() {
+		t.Error("Err(nil) 应该是成功的结果")
+	}
+}
+
+// TestCollect 测试收集结果并聚合错误
+func TestCollect(t *testing.T) {
+	results := []Result[int]{
+		Ok(1),
+		Err[int](FastNew(ErrNotFound)),
+		Ok(2),
+		Err[int](FastNew(ErrExternal)),
+	}
+
+	values, err := Collect(results)
+	if !slices.Equal(values, []int{1, 2}) {
+		t.Errorf("values = %v", values)
+	}
+	if err == nil || err.HttpStatus() != http.StatusBadGateway || len(err.(*MultiError).Errors()) != 2 {
+		t.Errorf("err = %v", err)
+	}
+
+	ch := make(chan Result[int], 3)
+	ch <- Ok(1)
+	ch <- Ok(2)
+	ch <- Ok(3)
+	close(ch)
+	values, err = CollectChan(ch)
+	if !slices.Equal(values, []int{1, 2, 3}) || err != nil {
+		t.Errorf("CollectChan() = %v, %v", values, err)
+	}
+}
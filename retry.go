@@ -0,0 +1,223 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// Retryability 错误码或错误实例上的重试标记
+type Retryability int
+
+const (
+	// RetryByType 按错误类型判断是否可重试
+	RetryByType Retryability = iota
+	// RetryEnabled 始终可重试
+	RetryEnabled
+	// RetryDisabled 始终不可重试
+	RetryDisabled
+)
+
+// RetryableError 可以显式声明重试标记的错误，ErrorImpl实现了该接口
+type RetryableError interface {
+	Retryability() Retryability
+}
+
+// IsRetryable 判断错误是否可以重试
+// 优先使用错误码或构造器指定的重试标记，未指定时按错误类型判断：TIMEOUT和RATE_LIMIT可重试，
+// EXTERNAL在下游返回5xx、408、429或没有下游状态码时可重试，其它类型不可重试。
+// MultiError（包括被包装的）只有在所有子错误都可重试时才可重试，非Error类型的错误不可重试
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var m *MultiError
+	if errors.As(err, &m) {
+		if m.Len() == 0 {
+			return false
+		}
+		for _, child := range m.Errors() {
+			if !IsRetryable(child) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var e Error
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	if r, ok := e.(RetryableError); ok {
+		switch r.Retryability() {
+		case RetryEnabled:
+			return true
+		case RetryDisabled:
+			return false
+		}
+	}
+
+	switch e.Type() {
+	case ErrTypeTimeout, ErrTypeRateLimit:
+		return true
+	case ErrTypeExternal:
+		status, ok := e.Metadata()["upstream_status"].(int)
+		if !ok {
+			return true
+		}
+		return status >= http.StatusInternalServerError ||
+			status == http.StatusRequestTimeout ||
+			status == http.StatusTooManyRequests
+	default:
+		return false
+	}
+}
+
+// RetryAfter 返回错误中建议的重试等待时间
+func RetryAfter(err error) (time.Duration, bool) {
+	hint, ok := GetRetryHint(err)
+	if !ok || hint.RetryAfter <= 0 {
+		return 0, false
+	}
+
+	return hint.RetryAfter, true
+}
+
+// RetryPolicy 重试策略，零值字段使用DefaultRetryPolicy中的默认值
+type RetryPolicy struct {
+	// 最大尝试次数，包含第一次调用
+	MaxAttempts int
+	// 第一次重试前的等待时间
+	InitialInterval time.Duration
+	// 最大等待时间，同时是错误建议的重试等待时间的上限
+	MaxInterval time.Duration
+	// 等待时间的增长倍数
+	Multiplier float64
+	// 抖动比例，取值[0, 1]，实际等待时间在 interval*(1±Jitter) 之间
+	Jitter float64
+	// 时钟，测试中可以注入假时钟
+	Clock Clock
+	// 随机数生成函数，返回[0, 1)，测试中可以注入固定值
+	Rand func() float64
+}
+
+// DefaultRetryPolicy 默认的重试策略：最多3次，100ms起步，2倍增长，最大10s，20%抖动
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     3,
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		Clock:           SystemClock{},
+		Rand:            rand.Float64,
+	}
+}
+
+// withDefaults 使用默认值填充零值字段
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	defaults := DefaultRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = defaults.MaxAttempts
+	}
+	if p.InitialInterval <= 0 {
+		p.InitialInterval = defaults.InitialInterval
+	}
+	if p.MaxInterval <= 0 {
+		p.MaxInterval = defaults.MaxInterval
+	}
+	if p.Multiplier < 1 {
+		p.Multiplier = defaults.Multiplier
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		p.Jitter = defaults.Jitter
+	}
+	if p.Clock == nil {
+		p.Clock = defaults.Clock
+	}
+	if p.Rand == nil {
+		p.Rand = defaults.Rand
+	}
+
+	return p
+}
+
+// jitter 为等待时间添加随机抖动
+func (p RetryPolicy) jitter(interval time.Duration) time.Duration {
+	if p.Jitter == 0 {
+		return interval
+	}
+
+	delta := p.Jitter * (2*p.Rand() - 1)
+	return time.Duration(float64(interval) * (1 + delta))
+}
+
+// contextError 将上下文结束的原因转换为Error，超时转换为可重试的TIMEOUT，主动取消转换为不可重试的CANCELED
+func contextError(err error) Error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return FastWrap(err, ErrTimeout)
+	}
+
+	return FastWrap(err, ErrCanceled)
+}
+
+// Retry 按重试策略执行fn，直到成功、遇到不可重试的错误、达到最大尝试次数或ctx结束
+// 成功时返回nil，否则返回包含所有尝试错误的MultiError，其Code、Type和HttpStatus以最后一次错误为准。
+// ctx超时时最后一个错误为TIMEOUT，ctx被取消时为不可重试的CANCELED。
+// 错误中带有建议的重试等待时间且大于退避时间时，使用建议的等待时间，但不超过MaxInterval，
+// 避免下游返回过大的Retry-After时长时间阻塞调用方
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) Error {
+	policy = policy.withDefaults()
+	failures := NewMultiError(AggregateLast)
+	interval := policy.InitialInterval
+
+	attempt := 1
+	for ; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+
+		failures.Append(err)
+		if attempt >= policy.MaxAttempts || !IsRetryable(err) {
+			break
+		}
+
+		wait := policy.jitter(interval)
+		if hint, ok := RetryAfter(err); ok && hint > wait {
+			wait = min(hint, policy.MaxInterval)
+		}
+
+		select {
+		case <-ctx.Done():
+			failures.Append(contextError(ctx.Err()))
+			return failures.WithMetadata("attempts", attempt)
+		case <-policy.Clock.After(wait):
+		}
+
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+		if interval > policy.MaxInterval {
+			interval = policy.MaxInterval
+		}
+	}
+
+	return failures.WithMetadata("attempts", attempt)
+}
@@ -0,0 +1,219 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestIsRetryable 测试按错误类型和重试标记判断是否可重试
+func TestIsRetryable(t *testing.T) {
+	retryableConflict := &ErrCode{
+		Code:       "TEST_OPTIMISTIC_LOCK",
+		Message:    "optimistic lock conflict",
+		HttpStatus: http.StatusConflict,
+		Type:       ErrTypeConflict,
+		Retryable:  RetryEnabled,
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "超时", err: FastNew(ErrTimeout), want: true},
+		{name: "限流", err: FastNew(ErrRateLimit), want: true},
+		{name: "参数校验", err: NewValidationError(), want: false},
+		{name: "资源不存在", err: FastNew(ErrNotFound), want: false},
+		{name: "错误码指定可重试", err: FastNew(retryableConflict), want: true},
+		{name: "构造器指定不可重试", err: NewBuilder().WithCode(ErrTimeout).WithRetryable(false).Build(), want: false},
+		{name: "下游5xx", err: FastNew(ErrExternal).WithMetadata("upstream_status", 503), want: true},
+		{name: "下游4xx", err: FastNew(ErrExternal).WithMetadata("upstream_status", 400), want: false},
+		{name: "包装后的错误", err: fmt.Errorf("call: %w", FastNew(ErrTimeout)), want: true},
+		{name: "标准错误", err: fmt.Errorf("plain"), want: false},
+		{name: "部分不可重试的聚合错误", err: Join(FastNew(ErrTimeout), FastNew(ErrNotFound)), want: false},
+		{name: "包装后部分不可重试的聚合错误", err: fmt.Errorf("batch: %w", Join(FastNew(ErrTimeout), FastNew(ErrNotFound))), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("IsRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRetryAfter 测试读取建议的重试等待时间
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		val    any
+		want   time.Duration
+		wantOK bool
+	}{
+		{val: 2 * time.Second, want: 2 * time.Second, wantOK: true},
+		{val: 3, want: 3 * time.Second, wantOK: true},
+		{val: "1500ms", want: 1500 * time.Millisecond, wantOK: true},
+		{val: "4", want: 4 * time.Second, wantOK: true},
+		{val: "soon", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		got, ok := RetryAfter(FastNew(ErrRateLimit).WithMetadata(MetadataRetryAfter, tt.val))
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("RetryAfter(%v) = %v, %v, want %v, %v", tt.val, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// TestRetry 测试重试的退避、终止条件和聚合错误
+func TestRetry(t *testing.T) {
+	newPolicy := func(clock Clock) RetryPolicy {
+		return RetryPolicy{
+			MaxAttempts:     4,
+			InitialInterval: 100 * time.Millisecond,
+			MaxInterval:     300 * time.Millisecond,
+			Multiplier:      2,
+			Jitter:          0.5,
+			Clock:           clock,
+			Rand:            func() float64 { return 0.5 },
+		}
+	}
+
+	t.Run("退避后成功", func(t *testing.T) {
+		clock := newFakeClock()
+		calls := 0
+		err := Retry(context.Background(), newPolicy(clock), func(ctx context.Context) error {
+			calls++
+			if calls < 3 {
+				return FastNew(ErrTimeout)
+			}
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Retry() error = %v", err)
+		}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}
+		if got := clock.Waits(); !reflect.DeepEqual(got, want) {
+			t.Errorf("waits = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("达到最大次数", func(t *testing.T) {
+		clock := newFakeClock()
+		err := Retry(context.Background(), newPolicy(clock), func(ctx context.Context) error {
+			return FastNew(ErrTimeout)
+		})
+
+		m, ok := err.(*MultiError)
+		if !ok || m.Len() != 4 {
+			t.Fatalf("期望得到4次尝试的MultiError，但得到了 %v", err)
+		}
+		if err.Metadata()["attempts"] != 4 || err.Type() != ErrTypeTimeout {
+			t.Errorf("attempts = %v, Type() = %v", err.Metadata()["attempts"], err.Type())
+		}
+		want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond}
+		if got := clock.Waits(); !reflect.DeepEqual(got, want) {
+			t.Errorf("waits = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("不可重试的错误立即停止", func(t *testing.T) {
+		clock := newFakeClock()
+		calls := 0
+		err := Retry(context.Background(), newPolicy(clock), func(ctx context.Context) error {
+			calls++
+			if calls == 1 {
+				return FastNew(ErrTimeout)
+			}
+			return FastNew(ErrNotFound)
+		})
+
+		if calls != 2 || err.Type() != ErrTypeNotFound || err.HttpStatus() != http.StatusNotFound {
+			t.Errorf("calls = %d, Type() = %v", calls, err.Type())
+		}
+	})
+
+	t.Run("使用建议的等待时间", func(t *testing.T) {
+		clock := newFakeClock()
+		policy := newPolicy(clock)
+		policy.MaxAttempts = 2
+		policy.MaxInterval = 10 * time.Second
+		_ = Retry(context.Background(), policy, func(ctx context.Context) error {
+			return FastNew(ErrRateLimit).WithMetadata(MetadataRetryAfter, 5)
+		})
+
+		if got := clock.Waits(); !reflect.DeepEqual(got, []time.Duration{5 * time.Second}) {
+			t.Errorf("waits = %v, want [5s]", got)
+		}
+	})
+
+	t.Run("建议的等待时间不超过MaxInterval", func(t *testing.T) {
+		clock := newFakeClock()
+		policy := newPolicy(clock)
+		policy.MaxAttempts = 2
+		_ = Retry(context.Background(), policy, func(ctx context.Context) error {
+			return FastNew(ErrRateLimit).WithMetadata(MetadataRetryAfter, 86400)
+		})
+
+		if got := clock.Waits(); !reflect.DeepEqual(got, []time.Duration{policy.MaxInterval}) {
+			t.Errorf("waits = %v, want [%s]", got, policy.MaxInterval)
+		}
+	})
+	t.Run("上下文结束", func(t *testing.T) {
+		canceled, cancel := context.WithCancel(context.Background())
+		cancel()
+		expired, cancelExpired := context.WithDeadline(context.Background(), time.Unix(0, 0))
+		defer cancelExpired()
+
+		tests := []struct {
+			name          string
+			ctx           context.Context
+			wantCode      string
+			wantStatus    int
+			wantRetryable bool
+		}{
+			{name: "主动取消", ctx: canceled, wantCode: ErrCanceled.Code, wantStatus: StatusClientClosedRequest},
+			{name: "超时", ctx: expired, wantCode: ErrTimeout.Code, wantStatus: http.StatusRequestTimeout, wantRetryable: true},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				// 等待时间足够长，保证先观察到上下文结束
+				policy := newPolicy(SystemClock{})
+				policy.InitialInterval = time.Hour
+				policy.MaxInterval = time.Hour
+				err := Retry(tt.ctx, policy, func(ctx context.Context) error {
+					return FastNew(ErrTimeout)
+				})
+
+				if err.Code() != tt.wantCode || err.HttpStatus() != tt.wantStatus {
+					t.Errorf("Code() = %s, HttpStatus() = %d, want %s, %d", err.Code(), err.HttpStatus(), tt.wantCode, tt.wantStatus)
+				}
+				errs := err.(*MultiError).Errors()
+				if got := IsRetryable(errs[len(errs)-1]); got != tt.wantRetryable {
+					t.Errorf("IsRetryable() = %v, want %v", got, tt.wantRetryable)
+				}
+			})
+		}
+	})
+}
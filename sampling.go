@@ -0,0 +1,252 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SampleKey 采样计数的维度
+type SampleKey int
+
+const (
+	// SampleByCode 按错误码计数
+	SampleByCode SampleKey = iota
+	// SampleByFingerprint 按错误指纹计数，同一错误码下不同位置产生的错误分别采样
+	SampleByFingerprint
+)
+
+// SampleSummary 一个采样周期内被丢弃日志的汇总
+type SampleSummary struct {
+	// 采样的键，按错误码计数时为错误码，按指纹计数时为指纹
+	Key string `json:"key"`
+	// 错误码
+	Code string `json:"code"`
+	// 周期内出现的次数
+	Count int64 `json:"count"`
+	// 周期内被丢弃的次数
+	Dropped int64 `json:"dropped"`
+	// 周期开始时间
+	Start time.Time `json:"start"`
+	// 周期长度
+	Interval time.Duration `json:"interval"`
+}
+
+// sampleCounter 单个键在当前周期内的计数
+type sampleCounter struct {
+	code    string
+	start   time.Time
+	count   int64
+	dropped int64
+}
+
+// Sampler 高频错误的日志采样器，每个周期内每个键前first次全部记录，之后每thereafter次记录一次，
+// 被丢弃的次数在周期结束后汇总为一条摘要，并发安全。
+// 摘要只在Sample、Expire、Flush被调用时产生，错误停止到达后需要通过Run（或Handler.RunSampling）
+// 定期输出，否则最后一个周期的摘要会一直延迟到下一次错误或Flush
+type Sampler struct {
+	mu sync.Mutex
+	// 时钟
+	clock Clock
+	// 采样周期
+	interval time.Duration
+	// 每个周期内全部记录的次数
+	first int64
+	// 超过first之后每thereafter次记录一次，0表示全部丢弃
+	thereafter int64
+	// 计数维度
+	key SampleKey
+	// 按指纹计数时使用的指纹计算器
+	fingerprinter *Fingerprinter
+	// 键 -> 计数
+	counters map[string]*sampleCounter
+	// 上一次清理已结束周期的时间
+	lastSweep time.Time
+}
+
+type SamplerOption func(s *Sampler)
+
+// WithSampleInterval 设置采样周期，默认1分钟
+func WithSampleInterval(d time.Duration) SamplerOption {
+	return func(s *Sampler) {
+		if d > 0 {
+			s.interval = d
+		}
+	}
+}
+
+// WithSampleFirst 设置每个周期内每个键全部记录的次数，默认100
+func WithSampleFirst(n int) SamplerOption {
+	return func(s *Sampler) {
+		s.first = int64(max(0, n))
+	}
+}
+
+// WithSampleThereafter 设置超过first之后的采样间隔，每n次记录一次，默认100，0表示全部丢弃
+func WithSampleThereafter(n int) SamplerOption {
+	return func(s *Sampler) {
+		s.thereafter = int64(max(0, n))
+	}
+}
+
+// WithSampleKey 设置计数维度，默认按错误码
+func WithSampleKey(key SampleKey) SamplerOption {
+	return func(s *Sampler) {
+		s.key = key
+	}
+}
+
+// WithSampleFingerprinter 设置按指纹计数时使用的指纹计算器，默认为DefaultFingerprinter
+func WithSampleFingerprinter(f *Fingerprinter) SamplerOption {
+	return func(s *Sampler) {
+		s.fingerprinter = f
+	}
+}
+
+// WithSamplerClock 设置时钟，默认为系统时钟
+func WithSamplerClock(clock Clock) SamplerOption {
+	return func(s *Sampler) {
+		s.clock = clock
+	}
+}
+
+// NewSampler 创建日志采样器
+func NewSampler(opts ...SamplerOption) *Sampler {
+	s := &Sampler{
+		clock:         SystemClock{},
+		interval:      time.Minute,
+		first:         100,
+		thereafter:    100,
+		key:           SampleByCode,
+		fingerprinter: DefaultFingerprinter,
+		counters:      make(map[string]*sampleCounter),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Sample 判断本次错误是否需要记录日志，同时返回已经结束的周期中有丢弃的摘要，
+// 摘要在有新的错误到达时按周期批量产生，没有新的错误时由Run或Expire输出
+func (s *Sampler) Sample(err Error) (bool, []SampleSummary) {
+	if err == nil {
+		return false, nil
+	}
+
+	key := err.Code()
+	if s.key == SampleByFingerprint {
+		key = s.fingerprinter.Fingerprint(err)
+	}
+	now := s.clock.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var summaries []SampleSummary
+	if now.Sub(s.lastSweep) >= s.interval {
+		summaries = s.sweep(now, false)
+		s.lastSweep = now
+	}
+
+	counter, ok := s.counters[key]
+	if !ok || now.Sub(counter.start) >= s.interval {
+		if ok && counter.dropped > 0 {
+			summaries = append(summaries, s.summary(key, counter, now))
+		}
+		counter = &sampleCounter{code: err.Code(), start: now}
+		s.counters[key] = counter
+	}
+
+	counter.count++
+	if counter.count <= s.first ||
+		(s.thereafter > 0 && (counter.count-s.first)%s.thereafter == 0) {
+		return true, summaries
+	}
+
+	counter.dropped++
+	return false, summaries
+}
+
+// Expire 移除已经结束的周期，返回其中有丢弃的摘要，未结束的周期继续计数
+func (s *Sampler) Expire() []SampleSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	s.lastSweep = now
+	return s.sweep(now, false)
+}
+
+// Run 每个采样周期调用一次Expire，直到ctx结束后调用Flush，有摘要时交给emit处理
+func (s *Sampler) Run(ctx context.Context, emit func([]SampleSummary)) {
+	for {
+		select {
+		case <-ctx.Done():
+			if summaries := s.Flush(); len(summaries) > 0 {
+				emit(summaries)
+			}
+			return
+		case <-s.clock.After(s.interval):
+			if summaries := s.Expire(); len(summaries) > 0 {
+				emit(summaries)
+			}
+		}
+	}
+}
+
+// Flush 结束所有周期，返回有丢弃的摘要并清空计数
+func (s *Sampler) Flush() []SampleSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.sweep(s.clock.Now(), true)
+}
+
+// sweep 移除已经结束的周期，all为true时移除全部周期，需要持有锁
+func (s *Sampler) sweep(now time.Time, all bool) []SampleSummary {
+	var summaries []SampleSummary
+	for key, counter := range s.counters {
+		if !all && now.Sub(counter.start) < s.interval {
+			continue
+		}
+		if counter.dropped > 0 {
+			summaries = append(summaries, s.summary(key, counter, now))
+		}
+		delete(s.counters, key)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Key < summaries[j].Key
+	})
+
+	return summaries
+}
+
+// summary 生成周期摘要，周期长度取实际经过的时间和采样周期中较小的一个
+func (s *Sampler) summary(key string, counter *sampleCounter, now time.Time) SampleSummary {
+	return SampleSummary{
+		Key:      key,
+		Code:     counter.code,
+		Count:    counter.count,
+		Dropped:  counter.dropped,
+		Start:    counter.start,
+		Interval: min(now.Sub(counter.start), s.interval),
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestSampler 测试先全部记录、之后按间隔采样以及周期结束后的摘要
+func TestSampler(t *testing.T) {
+	clock := newFakeClock()
+	s := NewSampler(WithSamplerClock(clock), WithSampleFirst(2), WithSampleThereafter(3), WithSampleInterval(time.Minute))
+
+	var kept []int
+	for i := 1; i <= 10; i++ {
+		keep, summaries := s.Sample(FastNew(ErrTimeout))
+		if len(summaries) != 0 {
+			t.Fatalf("周期内不应该产生摘要: %+v", summaries)
+		}
+		if keep {
+			kept = append(kept, i)
+		}
+	}
+	if want := []int{1, 2, 5, 8}; len(kept) != len(want) || kept[2] != 5 || kept[3] != 8 {
+		t.Errorf("kept = %v, want %v", kept, want)
+	}
+
+	// 不同的错误码独立计数
+	if keep, _ := s.Sample(FastNew(ErrNotFound)); !keep {
+		t.Error("新的错误码应该被记录")
+	}
+
+	clock.Advance(time.Minute)
+	keep, summaries := s.Sample(FastNew(ErrTimeout))
+	if !keep {
+		t.Error("新周期的第一次错误应该被记录")
+	}
+	if len(summaries) != 1 {
+		t.Fatalf("summaries = %+v", summaries)
+	}
+	if got := summaries[0]; got.Code != ErrTimeout.Code || got.Count != 10 || got.Dropped != 6 || got.Interval != time.Minute {
+		t.Errorf("summary = %+v", got)
+	}
+
+	if got := s.Flush(); len(got) != 0 {
+		t.Errorf("没有丢弃时不应该产生摘要: %+v", got)
+	}
+	if keep, _ := s.Sample(nil); keep {
+		t.Error("nil不应该被记录")
+	}
+}
+
+// TestSamplerByFingerprint 测试按指纹计数
+func TestSamplerByFingerprint(t *testing.T) {
+	s := NewSampler(WithSampleKey(SampleByFingerprint), WithSampleFirst(1), WithSampleThereafter(0))
+
+	// 原始错误的类型不同，指纹不同
+	a, b := FastWrap(context.DeadlineExceeded, ErrTimeout), FastWrap(errors.New("i/o timeout"), ErrTimeout)
+	for _, err := range []Error{a, b} {
+		if keep, _ := s.Sample(err); !keep {
+			t.Error("不同指纹的第一次错误应该被记录")
+		}
+	}
+	s.Sample(a)
+
+	summaries := s.Flush()
+	if len(summaries) != 1 || summaries[0].Key != Fingerprint(a) || summaries[0].Dropped != 1 {
+		t.Errorf("summaries = %+v", summaries)
+	}
+}
+
+// TestHandler_Sampling 测试采样丢弃的错误不记录日志但计入监控，并输出摘要
+func TestHandler_Sampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	clock := newFakeClock()
+	m := NewMonitor()
+	h := NewHandler(zap.New(core), WithMonitor(m),
+		WithSampler(NewSampler(WithSamplerClock(clock), WithSampleFirst(1), WithSampleThereafter(0))))
+
+	for range 5 {
+		h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), FastNew(ErrTimeout))
+	}
+	if logs.Len() != 1 {
+		t.Fatalf("logs = %d, want 1", logs.Len())
+	}
+	snapshot := m.Snapshot()
+	if snapshot.Total != 5 || snapshot.Dropped != 4 {
+		t.Errorf("total = %d, dropped = %d", snapshot.Total, snapshot.Dropped)
+	}
+
+	clock.Advance(30 * time.Second)
+	h.FlushSampling()
+	entries := logs.All()
+	if len(entries) != 2 || entries[1].Message != "code TIMEOUT occurred 5 times in 30s" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if fields := entries[1].ContextMap(); fields["dropped"] != int64(4) || fields["code"] != ErrTimeout.Code {
+		t.Errorf("fields = %v", fields)
+	}
+}
+
+// tickClock After返回由测试控制的通道，用于驱动周期任务
+type tickClock struct {
+	*fakeClock
+	tick chan time.Time
+}
+
+func (c *tickClock) After(time.Duration) <-chan time.Time {
+	return c.tick
+}
+
+// TestHandler_RunSampling 测试错误停止到达后摘要按周期输出，结束时输出剩余摘要
+func TestHandler_RunSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	clock := &tickClock{fakeClock: newFakeClock(), tick: make(chan time.Time)}
+	h := NewHandler(zap.New(core),
+		WithSampler(NewSampler(WithSamplerClock(clock), WithSampleFirst(1), WithSampleThereafter(0))))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		h.RunSampling(ctx)
+	}()
+
+	for range 3 {
+		h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), FastNew(ErrTimeout))
+	}
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), FastNew(ErrNotFound))
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), FastNew(ErrNotFound))
+
+	// 周期结束后没有新的错误，摘要由定时任务输出
+	clock.Advance(time.Minute)
+	clock.tick <- clock.Now()
+	// 再次发送确保上一次的摘要已经输出
+	clock.tick <- clock.Now()
+
+	entries := logs.FilterMessageSnippet("occurred").All()
+	if len(entries) != 2 || entries[0].Message != "code NOT_FOUND occurred 2 times in 60s" ||
+		entries[1].Message != "code TIMEOUT occurred 3 times in 60s" {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), FastNew(ErrTimeout))
+	h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), FastNew(ErrTimeout))
+	cancel()
+	<-done
+
+	entries = logs.FilterMessageSnippet("occurred").All()
+	if len(entries) != 3 || entries[2].ContextMap()["dropped"] != int64(1) {
+		t.Errorf("结束时应该输出剩余的摘要: %+v", entries)
+	}
+}
@@ -0,0 +1,480 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sentryClient 上报时使用的客户端标识
+const sentryClient = "go-errors/1.0"
+
+// maxSentryChain 导出的错误链最大层数
+const maxSentryChain = 16
+
+// SentryEvent Sentry事件
+type SentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Message     string            `json:"message,omitempty"`
+	Exception   *SentryExceptions `json:"exception,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Fingerprint []string          `json:"fingerprint,omitempty"`
+}
+
+// SentryExceptions Sentry事件的异常列表，按错误链从最内层的原始错误到最外层排列
+type SentryExceptions struct {
+	Values []SentryException `json:"values"`
+}
+
+// SentryException 错误链中的一层
+type SentryException struct {
+	Type       string            `json:"type"`
+	Value      string            `json:"value"`
+	Module     string            `json:"module,omitempty"`
+	Stacktrace *SentryStacktrace `json:"stacktrace,omitempty"`
+}
+
+// SentryStacktrace 结构化的堆栈，帧按调用顺序从最外层到最内层排列
+type SentryStacktrace struct {
+	Frames []SentryFrame `json:"frames"`
+}
+
+// SentryFrame 堆栈帧
+type SentryFrame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename,omitempty"`
+	AbsPath  string `json:"abs_path,omitempty"`
+	Lineno   int    `json:"lineno,omitempty"`
+	InApp    bool   `json:"in_app"`
+}
+
+// SentryTransport 发送Sentry事件的传输层
+type SentryTransport interface {
+	Send(ctx context.Context, event *SentryEvent) error
+}
+
+// MemoryTransport 将事件保存在内存中的传输层，用于测试
+type MemoryTransport struct {
+	mu     sync.Mutex
+	events []*SentryEvent
+}
+
+// NewMemoryTransport 创建内存传输层
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{}
+}
+
+func (t *MemoryTransport) Send(_ context.Context, event *SentryEvent) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = append(t.events, event)
+	return nil
+}
+
+// Events 返回已发送事件的副本
+func (t *MemoryTransport) Events() []*SentryEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.events)
+}
+
+// Reset 清空已发送的事件
+func (t *MemoryTransport) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.events = nil
+}
+
+// HTTPTransport 根据DSN通过envelope接口发送事件的传输层
+type HTTPTransport struct {
+	// 发送请求的客户端
+	client *http.Client
+	// envelope接口地址
+	endpoint string
+	// 原始DSN，写入envelope头
+	dsn string
+	// X-Sentry-Auth请求头
+	auth string
+}
+
+// NewHTTPTransport 根据DSN创建HTTP传输层，DSN格式为
+// {scheme}://{public_key}[:{secret_key}]@{host}[/{path}]/{project_id}，client为空时使用http.DefaultClient
+func NewHTTPTransport(dsn string, client *http.Client) (*HTTPTransport, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sentry dsn: %w", err)
+	}
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.New("invalid sentry dsn: missing public key")
+	}
+
+	projectID := path.Base(u.Path)
+	if projectID == "" || projectID == "/" || projectID == "." {
+		return nil, errors.New("invalid sentry dsn: missing project id")
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=%s, sentry_key=%s", sentryClient, u.User.Username())
+	if secret, ok := u.User.Password(); ok && secret != "" {
+		auth += ", sentry_secret=" + secret
+	}
+
+	prefix := strings.TrimSuffix(path.Dir(u.Path), "/")
+	return &HTTPTransport{
+		client:   client,
+		endpoint: fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, prefix, projectID),
+		dsn:      dsn,
+		auth:     auth,
+	}, nil
+}
+
+// Endpoint 返回envelope接口地址
+func (t *HTTPTransport) Endpoint() string {
+	return t.endpoint
+}
+
+// Send 发送事件，服务端返回错误时返回DecodeResponse还原的Error，429时携带Retry-After提示
+func (t *HTTPTransport) Send(ctx context.Context, event *SentryEvent) error {
+	body, err := encodeEnvelope(event, t.dsn)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", t.auth)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if decoded := DecodeResponse(resp); decoded != nil {
+		return decoded
+	}
+
+	// 读完响应体后连接才能被复用
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+	return nil
+}
+
+// encodeEnvelope 将事件编码为envelope格式：envelope头、事件头和事件各占一行
+func encodeEnvelope(event *SentryEvent, dsn string) ([]byte, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := json.Marshal(map[string]any{
+		"event_id": event.EventID,
+		"dsn":      dsn,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := json.Marshal(map[string]any{
+		"type":   "event",
+		"length": len(payload),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Write(header)
+	buf.WriteByte('\n')
+	buf.Write(item)
+	buf.WriteByte('\n')
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// SentryExporter 将Error转换为Sentry事件并通过传输层发送
+type SentryExporter struct {
+	// 传输层
+	transport SentryTransport
+	// 指纹计算器
+	fingerprinter *Fingerprinter
+	// 脱敏器，作用于消息和元数据
+	redactor *Redactor
+	// 环境
+	environment string
+	// 版本
+	release string
+	// 服务器名称
+	serverName string
+	// 附加到每个事件的标签
+	tags map[string]string
+}
+
+type SentryOption func(x *SentryExporter)
+
+// WithSentryEnvironment 设置事件的环境
+func WithSentryEnvironment(environment string) SentryOption {
+	return func(x *SentryExporter) {
+		x.environment = environment
+	}
+}
+
+// WithSentryRelease 设置事件的版本
+func WithSentryRelease(release string) SentryOption {
+	return func(x *SentryExporter) {
+		x.release = release
+	}
+}
+
+// WithSentryServerName 设置事件的服务器名称
+func WithSentryServerName(name string) SentryOption {
+	return func(x *SentryExporter) {
+		x.serverName = name
+	}
+}
+
+// WithSentryTags 设置附加到每个事件的标签
+func WithSentryTags(tags map[string]string) SentryOption {
+	return func(x *SentryExporter) {
+		for k, v := range tags {
+			x.tags[k] = v
+		}
+	}
+}
+
+// WithSentryRedactor 设置脱敏器，默认为DefaultRedactor，传入nil时关闭脱敏
+func WithSentryRedactor(r *Redactor) SentryOption {
+	return func(x *SentryExporter) {
+		x.redactor = r
+	}
+}
+
+// WithSentryFingerprinter 设置指纹计算器，默认为DefaultFingerprinter
+func WithSentryFingerprinter(f *Fingerprinter) SentryOption {
+	return func(x *SentryExporter) {
+		x.fingerprinter = f
+	}
+}
+
+// NewSentryExporter 创建Sentry导出器
+func NewSentryExporter(transport SentryTransport, opts ...SentryOption) *SentryExporter {
+	x := &SentryExporter{
+		transport:     transport,
+		fingerprinter: DefaultFingerprinter,
+		redactor:      DefaultRedactor(),
+		tags:          make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(x)
+	}
+
+	return x
+}
+
+// Capture 将错误转换为事件并发送，返回事件ID，err为nil时不发送
+func (x *SentryExporter) Capture(ctx context.Context, err error) (string, error) {
+	event := x.Event(err)
+	if event == nil {
+		return "", nil
+	}
+
+	if sendErr := x.transport.Send(ctx, event); sendErr != nil {
+		return "", sendErr
+	}
+
+	return event.EventID, nil
+}
+
+// Event 将错误转换为Sentry事件，err为nil时返回nil
+func (x *SentryExporter) Event(err error) *SentryEvent {
+	if err == nil {
+		return nil
+	}
+
+	e := asError(err)
+	event := &SentryEvent{
+		EventID:     newEventID(),
+		Timestamp:   e.Timestamp(),
+		Platform:    "go",
+		Level:       sentryLevel(SeverityOf(e)),
+		ServerName:  x.serverName,
+		Release:     x.release,
+		Environment: x.environment,
+		Message:     x.redactor.RedactString(e.Message()),
+		Exception:   &SentryExceptions{Values: x.exceptions(e)},
+		Tags:        make(map[string]string, len(x.tags)+4),
+		Fingerprint: []string{x.fingerprinter.Fingerprint(e)},
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	for k, v := range x.tags {
+		event.Tags[k] = v
+	}
+	event.Tags["code"] = e.Code()
+	event.Tags["type"] = e.Type().String()
+	event.Tags["severity"] = SeverityOf(e).String()
+	event.Tags["http_status"] = strconv.Itoa(e.HttpStatus())
+
+	if metadata := e.Metadata(); len(metadata) > 0 {
+		event.Extra = x.redactor.RedactMetadata(metadata)
+	}
+
+	return event
+}
+
+// exceptions 将错误链转换为异常列表，从最内层的原始错误到最外层排列
+func (x *SentryExporter) exceptions(err Error) []SentryException {
+	var values []SentryException
+	walkErrors(err, func(e error) bool {
+		// errors.Join等只用于聚合的错误不作为单独的一层
+		if _, ok := e.(Error); !ok {
+			if _, ok := e.(interface{ Unwrap() []error }); ok {
+				return false
+			}
+		}
+
+		values = append(values, x.exception(e))
+		return len(values) >= maxSentryChain
+	})
+
+	slices.Reverse(values)
+	return values
+}
+
+// exception 将错误链中的一层转换为异常
+func (x *SentryExporter) exception(err error) SentryException {
+	e, ok := err.(Error)
+	if !ok {
+		return SentryException{
+			Type:  fmt.Sprintf("%T", err),
+			Value: x.redactor.RedactString(err.Error()),
+		}
+	}
+
+	exception := SentryException{
+		Type:   e.Code(),
+		Value:  x.redactor.RedactString(e.Message()),
+		Module: fmt.Sprintf("%T", e),
+	}
+	if frames := sentryFrames(e.StackTrace()); len(frames) > 0 {
+		exception.Stacktrace = &SentryStacktrace{Frames: frames}
+	}
+
+	return exception
+}
+
+// sentryFrames 将堆栈文本转换为Sentry的帧，Sentry要求最内层的帧在最后
+func sentryFrames(stack string) []SentryFrame {
+	parsed := parseStackFrames(stack, 64)
+	frames := make([]SentryFrame, 0, len(parsed))
+	for _, f := range parsed {
+		module, function := splitFunctionName(f.function)
+		frame := SentryFrame{
+			Function: function,
+			Module:   module,
+			Filename: path.Base(f.file),
+			InApp:    isInApp(module, f.file),
+		}
+		if path.IsAbs(f.file) {
+			frame.AbsPath = f.file
+		}
+		frame.Lineno, _ = strconv.Atoi(f.line)
+		frames = append(frames, frame)
+	}
+
+	slices.Reverse(frames)
+	return frames
+}
+
+// splitFunctionName 将 "github.com/a/b.(*T).M" 拆分为包路径和函数名
+func splitFunctionName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
+
+// isInApp 判断帧是否属于应用代码，标准库和模块缓存中的依赖不属于应用代码
+func isInApp(module, file string) bool {
+	if strings.Contains(file, "/pkg/mod/") || strings.Contains(file, "/vendor/") {
+		return false
+	}
+
+	first, _, _ := strings.Cut(module, "/")
+	return module == "" || module == "main" || strings.Contains(first, ".")
+}
+
+// sentryLevel 将严重程度转换为Sentry的级别
+func sentryLevel(severity Severity) string {
+	switch severity {
+	case SeverityCritical:
+		return "fatal"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	case SeverityDebug:
+		return "debug"
+	default:
+		return "error"
+	}
+}
+
+// newEventID 生成32位十六进制的事件ID
+func newEventID() string {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
@@ -0,0 +1,217 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestSentryExporter_Event 测试错误链转换为Sentry事件
+func TestSentryExporter_Event(t *testing.T) {
+	x := NewSentryExporter(NewMemoryTransport(),
+		WithSentryEnvironment("prod"),
+		WithSentryRelease("v1.2.3"),
+		WithSentryTags(map[string]string{"service": "order"}))
+
+	cause := fmt.Errorf("query order: %w", io.ErrUnexpectedEOF)
+	err := NewBuilder().
+		WithCode(ErrInternal).
+		WithMessage("load order failed").
+		WithCause(cause).
+		WithMetadata("order_id", 42).
+		WithMetadata("password", "secret").
+		Build()
+
+	event := x.Event(err)
+	if len(event.EventID) != 32 || event.Platform != "go" || event.Level != "error" {
+		t.Errorf("event = %+v", event)
+	}
+	if event.Environment != "prod" || event.Release != "v1.2.3" {
+		t.Errorf("environment = %s, release = %s", event.Environment, event.Release)
+	}
+
+	values := event.Exception.Values
+	if len(values) != 3 {
+		t.Fatalf("exception values = %+v", values)
+	}
+	if values[0].Type != "*errors.errorString" || values[1].Type != "*fmt.wrapError" || values[2].Type != ErrInternal.Code {
+		t.Errorf("types = %s, %s, %s", values[0].Type, values[1].Type, values[2].Type)
+	}
+	if values[2].Value != "load order failed" || values[2].Stacktrace == nil {
+		t.Fatalf("outermost = %+v", values[2])
+	}
+
+	frames := values[2].Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if !strings.HasSuffix(last.Module, "go-errors") || last.Function != "TestSentryExporter_Event" ||
+		last.Filename != "sentry_test.go" || last.Lineno == 0 || !last.InApp || last.AbsPath == "" {
+		t.Errorf("最内层的帧应该是调用处: %+v", last)
+	}
+
+	if event.Tags["code"] != ErrInternal.Code || event.Tags["type"] != "INTERNAL" ||
+		event.Tags["http_status"] != "500" || event.Tags["service"] != "order" {
+		t.Errorf("tags = %v", event.Tags)
+	}
+	if event.Extra["order_id"] != 42 || event.Extra["password"] != DefaultRedactMask {
+		t.Errorf("extra = %v", event.Extra)
+	}
+	if len(event.Fingerprint) != 1 || event.Fingerprint[0] != Fingerprint(err) {
+		t.Errorf("fingerprint = %v", event.Fingerprint)
+	}
+
+	critical := FastNew(&ErrCode{Code: "LOST", Type: ErrTypeBusiness, Severity: SeverityCritical})
+	if level := x.Event(critical).Level; level != "fatal" {
+		t.Errorf("level = %s, want fatal", level)
+	}
+	if x.Event(nil) != nil {
+		t.Error("Event(nil) 应该返回nil")
+	}
+}
+
+// TestSentryExporter_MemoryTransport 测试内存传输层
+func TestSentryExporter_MemoryTransport(t *testing.T) {
+	transport := NewMemoryTransport()
+	x := NewSentryExporter(transport)
+
+	id, err := x.Capture(context.Background(), FastNew(ErrTimeout))
+	if err != nil || id == "" {
+		t.Fatalf("Capture() = %q, %v", id, err)
+	}
+	if id, _ := x.Capture(context.Background(), nil); id != "" {
+		t.Error("nil不应该被发送")
+	}
+
+	events := transport.Events()
+	if len(events) != 1 || events[0].EventID != id || events[0].Level != "error" {
+		t.Errorf("events = %+v", events)
+	}
+
+	transport.Reset()
+	if len(transport.Events()) != 0 {
+		t.Error("Reset后应该没有事件")
+	}
+}
+
+// TestHTTPTransport 测试通过DSN发送到本地的替代服务
+func TestHTTPTransport(t *testing.T) {
+	var (
+		gotPath  string
+		gotAuth  string
+		gotEvent SentryEvent
+		limited  bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if limited {
+			w.Header().Set(HeaderRetryAfter, "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("X-Sentry-Auth")
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 1<<20), 1<<20)
+		var lines []string
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+		if len(lines) != 3 || !strings.Contains(lines[1], `"type":"event"`) {
+			t.Errorf("envelope = %q", lines)
+			return
+		}
+		if err := json.Unmarshal([]byte(lines[2]), &gotEvent); err != nil {
+			t.Errorf("解析事件失败: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://public:secret@", 1) + "/sentry/7"
+	transport, err := NewHTTPTransport(dsn, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHTTPTransport() = %v", err)
+	}
+	if transport.Endpoint() != srv.URL+"/sentry/api/7/envelope/" {
+		t.Errorf("Endpoint() = %s", transport.Endpoint())
+	}
+
+	x := NewSentryExporter(transport)
+	id, err := x.Capture(context.Background(), New(ErrNotFound))
+	if err != nil {
+		t.Fatalf("Capture() = %v", err)
+	}
+	if gotPath != "/sentry/api/7/envelope/" || !strings.Contains(gotAuth, "sentry_key=public") ||
+		!strings.Contains(gotAuth, "sentry_secret=secret") {
+		t.Errorf("path = %s, auth = %s", gotPath, gotAuth)
+	}
+	if gotEvent.EventID != id || gotEvent.Tags["code"] != ErrNotFound.Code || gotEvent.Level != "info" {
+		t.Errorf("event = %+v", gotEvent)
+	}
+
+	limited = true
+	_, err = x.Capture(context.Background(), New(ErrNotFound))
+	if d, ok := RetryAfter(err); !ok || d != time.Minute {
+		t.Errorf("限流时应该返回重试提示, err = %v", err)
+	}
+
+	for _, dsn := range []string{"http://host/1", "http://key@host", "://bad"} {
+		if _, err := NewHTTPTransport(dsn, nil); err == nil {
+			t.Errorf("NewHTTPTransport(%q) 应该返回错误", dsn)
+		}
+	}
+}
+
+// TestHTTPTransport_ReuseConnection 测试成功的响应体被读完，连接可以复用
+func TestHTTPTransport_ReuseConnection(t *testing.T) {
+	var conns atomic.Int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		// 响应体较大，未读完就关闭时连接无法复用
+		_, _ = io.WriteString(w, `{"id":"fc6d8c0c43fc4630ad850ee518f1b9d0","padding":"`+strings.Repeat("x", 512<<10)+`"}`)
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "http://", "http://public@", 1) + "/1"
+	transport, err := NewHTTPTransport(dsn, srv.Client())
+	if err != nil {
+		t.Fatalf("NewHTTPTransport() = %v", err)
+	}
+	for range 3 {
+		if err := transport.Send(context.Background(), &SentryEvent{EventID: "fc6d8c0c43fc4630ad850ee518f1b9d0"}); err != nil {
+			t.Fatalf("Send() = %v", err)
+		}
+	}
+
+	if got := conns.Load(); got != 1 {
+		t.Errorf("新建连接数 = %d, want 1", got)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity 错误的严重程度，与错误类型相互独立，用于日志级别、监控和告警
+// 数值越大越严重，可以直接比较
+type Severity int
+
+const (
+	// SeverityByType 未指定，按错误类型推导
+	SeverityByType Severity = iota
+	SeverityDebug
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+var severityNames = map[Severity]string{
+	SeverityDebug:    "debug",
+	SeverityInfo:     "info",
+	SeverityWarning:  "warning",
+	SeverityError:    "error",
+	SeverityCritical: "critical",
+}
+
+func (s Severity) String() string {
+	if name, ok := severityNames[s]; ok {
+		return name
+	}
+
+	return ""
+}
+
+// ParseSeverity 解析严重程度名称，忽略大小写，同时接受"warn"
+func ParseSeverity(name string) (Severity, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warn" {
+		return SeverityWarning, nil
+	}
+
+	for s, n := range severityNames {
+		if n == name {
+			return s, nil
+		}
+	}
+
+	return SeverityByType, fmt.Errorf("unknown severity %q", name)
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *Severity) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*s = SeverityByType
+		return nil
+	}
+
+	parsed, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = parsed
+	return nil
+}
+
+// SeverityProvider 可以声明严重程度的错误，ErrorImpl和MultiError实现了该接口
+type SeverityProvider interface {
+	Severity() Severity
+}
+
+// TypeSeverity 返回错误类型默认的严重程度
+//   - INTERNAL、TIMEOUT、EXTERNAL -> error
+//   - BUSINESS、VALIDATION -> warning
+//   - 其它类型 -> info
+func TypeSeverity(t ErrType) Severity {
+	switch t {
+	case ErrTypeInternal, ErrTypeTimeout, ErrTypeExternal:
+		return SeverityError
+	case ErrTypeBusiness, ErrTypeValidation:
+		return SeverityWarning
+	default:
+		return SeverityInfo
+	}
+}
+
+// SeverityOf 返回错误的严重程度，优先使用SeverityProvider接口，其次按错误类型推导，
+// 非Error类型的错误为error，nil为SeverityByType
+func SeverityOf(err error) Severity {
+	if err == nil {
+		return SeverityByType
+	}
+
+	var s SeverityProvider
+	if errors.As(err, &s) {
+		if severity := s.Severity(); severity != SeverityByType {
+			return severity
+		}
+	}
+
+	var e Error
+	if errors.As(err, &e) {
+		return TypeSeverity(e.Type())
+	}
+
+	return SeverityError
+}
@@ -0,0 +1,128 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var errPaymentLost = &ErrCode{
+	Code:       "PAYMENT_LOST",
+	Message:    "payment lost",
+	HttpStatus: http.StatusInternalServerError,
+	Type:       ErrTypeBusiness,
+	Severity:   SeverityCritical,
+}
+
+// TestSeverityOf 测试严重程度的默认推导和覆盖
+func TestSeverityOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Severity
+	}{
+		{name: "internal", err: FastNew(ErrInternal), want: SeverityError},
+		{name: "validation", err: FastNew(ErrValidation), want: SeverityWarning},
+		{name: "not found", err: FastNew(ErrNotFound), want: SeverityInfo},
+		{name: "code override", err: FastNew(errPaymentLost), want: SeverityCritical},
+		{name: "wrapped", err: fmt.Errorf("charge: %w", FastWrap(errors.New("x"), errPaymentLost)), want: SeverityCritical},
+		{
+			name: "builder override",
+			err:  NewBuilder().WithCode(ErrInternal).WithSeverity(SeverityDebug).WithFastMode().Build(),
+			want: SeverityDebug,
+		},
+		{name: "multi", err: Join(FastNew(ErrNotFound), FastNew(errPaymentLost)), want: SeverityCritical},
+		{name: "plain", err: errors.New("plain"), want: SeverityError},
+		{name: "nil", err: nil, want: SeverityByType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SeverityOf(tt.err); got != tt.want {
+				t.Errorf("SeverityOf() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSeverity_Text 测试严重程度的解析和序列化
+func TestSeverity_Text(t *testing.T) {
+	if s, err := ParseSeverity("WARN"); err != nil || s != SeverityWarning {
+		t.Errorf("ParseSeverity(WARN) = %s, %v", s, err)
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("未知的严重程度应该返回错误")
+	}
+
+	data, err := json.Marshal(map[Severity]int{SeverityCritical: 1})
+	if err != nil || string(data) != `{"critical":1}` {
+		t.Errorf("json.Marshal() = %s, %v", data, err)
+	}
+
+	var cfg struct {
+		Severity Severity `json:"severity"`
+	}
+	if err := json.Unmarshal([]byte(`{"severity":"error"}`), &cfg); err != nil || cfg.Severity != SeverityError {
+		t.Errorf("json.Unmarshal() = %s, %v", cfg.Severity, err)
+	}
+}
+
+// TestHandler_SeverityLevel 测试日志级别和监控使用严重程度
+func TestHandler_SeverityLevel(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	m := NewMonitor()
+	h := NewHandler(zap.New(core), WithMonitor(m))
+
+	errs := []Error{
+		FastNew(errPaymentLost),
+		NewBuilder().WithCode(ErrInternal).WithSeverity(SeverityInfo).WithFastMode().Build(),
+		FastNew(ErrValidation),
+	}
+	for _, err := range errs {
+		h.WriteError(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), err)
+	}
+
+	want := []struct {
+		level    zapcore.Level
+		severity string
+	}{
+		{level: zapcore.ErrorLevel, severity: "critical"},
+		{level: zapcore.InfoLevel, severity: "info"},
+		{level: zapcore.WarnLevel, severity: "warning"},
+	}
+	entries := logs.All()
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %d, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		if entries[i].Level != w.level || entries[i].ContextMap()["severity"] != w.severity {
+			t.Errorf("entries[%d] = %s/%v, want %s/%s", i, entries[i].Level, entries[i].ContextMap()["severity"], w.level, w.severity)
+		}
+	}
+
+	s := m.Snapshot()
+	if s.BySeverity[SeverityCritical] != 1 || s.BySeverity[SeverityInfo] != 1 || s.BySeverity[SeverityWarning] != 1 {
+		t.Errorf("BySeverity = %v", s.BySeverity)
+	}
+}
@@ -0,0 +1,314 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SLORouteAny 匹配所有没有单独定义SLO的路由，每个路由分别统计
+const SLORouteAny = "*"
+
+// BurnRateWindow 多窗口燃烧率告警的一组窗口，长窗口和短窗口的燃烧率都超过Factor时告警，
+// 短窗口用于在问题恢复后尽快停止告警
+type BurnRateWindow struct {
+	// 长窗口
+	Long time.Duration `json:"long"`
+	// 短窗口
+	Short time.Duration `json:"short"`
+	// 燃烧率阈值，1表示恰好在整个周期内耗尽错误预算
+	Factor float64 `json:"factor"`
+}
+
+// DefaultBurnRateWindows 返回默认的多窗口多燃烧率组合，适用于30天周期的SLO
+//   - 1h/5m 14.4倍：2%的预算在1小时内耗尽
+//   - 6h/30m 6倍：5%的预算在6小时内耗尽
+//   - 1d/2h 3倍：10%的预算在1天内耗尽
+//   - 3d/6h 1倍：10%的预算在3天内耗尽
+func DefaultBurnRateWindows() []BurnRateWindow {
+	return []BurnRateWindow{
+		{Long: time.Hour, Short: 5 * time.Minute, Factor: 14.4},
+		{Long: 6 * time.Hour, Short: 30 * time.Minute, Factor: 6},
+		{Long: 24 * time.Hour, Short: 2 * time.Hour, Factor: 3},
+		{Long: 72 * time.Hour, Short: 6 * time.Hour, Factor: 1},
+	}
+}
+
+// SLO 单个路由的服务等级目标
+type SLO struct {
+	// 路由模板，与Gin的c.FullPath()一致，如/users/:id，SLORouteAny匹配所有未单独定义的路由
+	Route string
+	// 目标成功率，如0.999
+	Objective float64
+	// 错误预算的统计周期，默认30天
+	Period time.Duration
+	// 燃烧率窗口，默认为DefaultBurnRateWindows
+	Windows []BurnRateWindow
+	// 判断请求是否消耗错误预算，err为请求渲染的错误，没有错误时为nil，默认只有5xx响应消耗预算，
+	// 因此VALIDATION等4xx的客户端错误不计入
+	IsBad func(status int, err Error) bool
+}
+
+// defaultIsBad 默认只有5xx响应消耗错误预算
+func defaultIsBad(status int, _ Error) bool {
+	return status >= http.StatusInternalServerError
+}
+
+// BurnRateSnapshot 一组燃烧率窗口的当前状态
+type BurnRateSnapshot struct {
+	BurnRateWindow
+	// 长窗口的燃烧率
+	LongRate float64 `json:"longRate"`
+	// 短窗口的燃烧率
+	ShortRate float64 `json:"shortRate"`
+	// 两个窗口的燃烧率是否都超过阈值
+	Alerting bool `json:"alerting"`
+}
+
+// SLOSnapshot 单个路由的SLO状态
+type SLOSnapshot struct {
+	// 路由模板
+	Route string `json:"route"`
+	// 目标成功率
+	Objective float64 `json:"objective"`
+	// 统计周期
+	Period time.Duration `json:"period"`
+	// 周期内的请求数
+	Total int64 `json:"total"`
+	// 周期内消耗预算的请求数
+	Bad int64 `json:"bad"`
+	// 剩余的错误预算比例，1表示未消耗，小于0表示已超支
+	BudgetRemaining float64 `json:"budgetRemaining"`
+	// 各组窗口的燃烧率
+	BurnRates []BurnRateSnapshot `json:"burnRates"`
+	// 是否有任意一组窗口在告警
+	Alerting bool `json:"alerting"`
+}
+
+// sloBucket 一个时间桶内的请求统计
+type sloBucket struct {
+	start time.Time
+	total int64
+	bad   int64
+}
+
+// sloRoute 单个路由的统计，时间桶按时间从旧到新排列，只保存有请求的时间桶
+type sloRoute struct {
+	slo     SLO
+	buckets []sloBucket
+}
+
+// SLOTracker 按路由统计请求的成功率，计算错误预算和多窗口燃烧率，并发安全
+type SLOTracker struct {
+	mu sync.Mutex
+	// 时钟
+	clock Clock
+	// 时间桶的宽度
+	bucket time.Duration
+	// 路由模板 -> SLO定义
+	slos map[string]SLO
+	// 路由 -> 统计
+	routes map[string]*sloRoute
+}
+
+type SLOOption func(t *SLOTracker)
+
+// WithSLO 添加SLO定义，相同路由的定义后添加的生效
+func WithSLO(slos ...SLO) SLOOption {
+	return func(t *SLOTracker) {
+		for _, slo := range slos {
+			t.slos[slo.Route] = slo
+		}
+	}
+}
+
+// WithSLOClock 设置时钟，默认为系统时钟
+func WithSLOClock(clock Clock) SLOOption {
+	return func(t *SLOTracker) {
+		t.clock = clock
+	}
+}
+
+// WithSLOBucket 设置时间桶的宽度，默认1分钟，宽度越小短窗口越精确，占用的内存越多
+func WithSLOBucket(d time.Duration) SLOOption {
+	return func(t *SLOTracker) {
+		if d > 0 {
+			t.bucket = d
+		}
+	}
+}
+
+// NewSLOTracker 创建SLO统计
+func NewSLOTracker(opts ...SLOOption) *SLOTracker {
+	t := &SLOTracker{
+		clock:  SystemClock{},
+		bucket: time.Minute,
+		slos:   make(map[string]SLO),
+		routes: make(map[string]*sloRoute),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	for route, slo := range t.slos {
+		if slo.Period <= 0 {
+			slo.Period = 30 * 24 * time.Hour
+		}
+		if len(slo.Windows) == 0 {
+			slo.Windows = DefaultBurnRateWindows()
+		}
+		if slo.IsBad == nil {
+			slo.IsBad = defaultIsBad
+		}
+		t.slos[route] = slo
+	}
+
+	return t
+}
+
+// Record 记录一次请求，route为路由模板，status为响应状态码，err为请求渲染的错误，
+// 没有定义SLO的路由或空路由会被忽略
+func (t *SLOTracker) Record(route string, status int, err Error) {
+	if t == nil || route == "" {
+		return
+	}
+
+	now := t.clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	r, ok := t.routes[route]
+	if !ok {
+		slo, defined := t.slos[route]
+		if !defined {
+			if slo, defined = t.slos[SLORouteAny]; !defined {
+				return
+			}
+		}
+		r = &sloRoute{slo: slo}
+		t.routes[route] = r
+	}
+
+	start := now.Truncate(t.bucket)
+	if n := len(r.buckets); n == 0 || r.buckets[n-1].start.Before(start) {
+		r.buckets = append(r.buckets, sloBucket{start: start})
+		r.prune(now, t.bucket)
+	}
+
+	b := &r.buckets[len(r.buckets)-1]
+	b.total++
+	if r.slo.IsBad(status, err) {
+		b.bad++
+	}
+}
+
+// Snapshot 返回所有路由的SLO状态，按路由排序
+func (t *SLOTracker) Snapshot() []SLOSnapshot {
+	if t == nil {
+		return nil
+	}
+
+	now := t.clock.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	snapshots := make([]SLOSnapshot, 0, len(t.routes))
+	for route, r := range t.routes {
+		snapshots = append(snapshots, r.snapshot(route, now))
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].Route < snapshots[j].Route
+	})
+
+	return snapshots
+}
+
+// prune 移除超出保留时间的时间桶，保留时间为统计周期和最长窗口中较大的一个
+func (r *sloRoute) prune(now time.Time, bucket time.Duration) {
+	retention := r.slo.Period
+	for _, w := range r.slo.Windows {
+		retention = max(retention, w.Long)
+	}
+
+	cutoff := now.Add(-retention - bucket)
+	i := sort.Search(len(r.buckets), func(i int) bool {
+		return r.buckets[i].start.After(cutoff)
+	})
+	if i > 0 {
+		r.buckets = append(r.buckets[:0], r.buckets[i:]...)
+	}
+}
+
+// sum 返回窗口内的请求数和消耗预算的请求数，起始时间在窗口内的时间桶会被计入，
+// 当前时间桶只统计了一部分，因此窗口的精度为一个时间桶的宽度
+func (r *sloRoute) sum(now time.Time, window time.Duration) (int64, int64) {
+	var total, bad int64
+	from := now.Add(-window)
+	for i := len(r.buckets) - 1; i >= 0; i-- {
+		b := r.buckets[i]
+		if !b.start.After(from) {
+			break
+		}
+		total += b.total
+		bad += b.bad
+	}
+
+	return total, bad
+}
+
+// burnRate 返回窗口内的燃烧率，即错误率与错误预算的比值
+func (r *sloRoute) burnRate(now time.Time, window time.Duration) float64 {
+	total, bad := r.sum(now, window)
+	budget := 1 - r.slo.Objective
+	if total == 0 || budget <= 0 {
+		return 0
+	}
+
+	return float64(bad) / float64(total) / budget
+}
+
+// snapshot 生成路由的SLO状态
+func (r *sloRoute) snapshot(route string, now time.Time) SLOSnapshot {
+	total, bad := r.sum(now, r.slo.Period)
+	snapshot := SLOSnapshot{
+		Route:           route,
+		Objective:       r.slo.Objective,
+		Period:          r.slo.Period,
+		Total:           total,
+		Bad:             bad,
+		BudgetRemaining: 1,
+	}
+	if total > 0 && r.slo.Objective < 1 {
+		snapshot.BudgetRemaining = 1 - float64(bad)/float64(total)/(1-r.slo.Objective)
+	}
+
+	for _, w := range r.slo.Windows {
+		rate := BurnRateSnapshot{
+			BurnRateWindow: w,
+			LongRate:       r.burnRate(now, w.Long),
+			ShortRate:      r.burnRate(now, w.Short),
+		}
+		rate.Alerting = rate.LongRate > w.Factor && rate.ShortRate > w.Factor
+		snapshot.Alerting = snapshot.Alerting || rate.Alerting
+		snapshot.BurnRates = append(snapshot.BurnRates, rate)
+	}
+
+	return snapshot
+}
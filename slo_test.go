@@ -0,0 +1,231 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+// TestSLOTracker 测试错误预算和多窗口燃烧率
+func TestSLOTracker(t *testing.T) {
+	clock := newFakeClock()
+	tracker := NewSLOTracker(WithSLOClock(clock), WithSLO(SLO{
+		Route:     "/pay",
+		Objective: 0.99,
+		Period:    time.Hour,
+		Windows:   []BurnRateWindow{{Long: time.Hour, Short: 5 * time.Minute, Factor: 10}},
+	}))
+
+	// 前55分钟全部成功，最后5分钟一半失败，客户端错误不计入
+	for minute := range 60 {
+		if minute > 0 {
+			clock.Advance(time.Minute)
+		}
+		for i := range 100 {
+			switch {
+			case minute >= 55 && i < 50:
+				tracker.Record("/pay", http.StatusBadGateway, FastNew(ErrExternal))
+			case i == 99:
+				tracker.Record("/pay", http.StatusUnprocessableEntity, FastNew(ErrValidation))
+			default:
+				tracker.Record("/pay", http.StatusOK, nil)
+			}
+		}
+	}
+	tracker.Record("/health", http.StatusInternalServerError, nil)
+	tracker.Record("", http.StatusInternalServerError, nil)
+
+	snapshots := tracker.Snapshot()
+	if len(snapshots) != 1 {
+		t.Fatalf("未定义SLO的路由不应该被统计: %+v", snapshots)
+	}
+	s := snapshots[0]
+	if s.Total != 6000 || s.Bad != 250 {
+		t.Errorf("total = %d, bad = %d", s.Total, s.Bad)
+	}
+	if !almostEqual(s.BudgetRemaining, 1-250.0/6000/0.01) {
+		t.Errorf("BudgetRemaining = %v", s.BudgetRemaining)
+	}
+
+	rate := s.BurnRates[0]
+	if !almostEqual(rate.LongRate, 250.0/6000/0.01) || !almostEqual(rate.ShortRate, 50) {
+		t.Errorf("long = %v, short = %v", rate.LongRate, rate.ShortRate)
+	}
+	if rate.Alerting || s.Alerting {
+		t.Error("长窗口没有超过阈值时不应该告警")
+	}
+
+	// 继续失败直到长窗口也超过阈值
+	for range 10 {
+		clock.Advance(time.Minute)
+		for range 100 {
+			tracker.Record("/pay", http.StatusServiceUnavailable, nil)
+		}
+	}
+	if s := tracker.Snapshot()[0]; !s.Alerting || s.BudgetRemaining >= 0 {
+		t.Errorf("snapshot = %+v", s)
+	}
+
+	// 恢复后短窗口很快低于阈值，停止告警
+	for range 6 {
+		clock.Advance(time.Minute)
+		for range 100 {
+			tracker.Record("/pay", http.StatusOK, nil)
+		}
+	}
+	if s := tracker.Snapshot()[0]; s.Alerting || s.BurnRates[0].LongRate <= 10 {
+		t.Errorf("snapshot = %+v", s)
+	}
+}
+
+// TestHandler_SLO 测试Gin中间件按路由模板记录SLO，并通过监控快照和Prometheus输出
+func TestHandler_SLO(t *testing.T) {
+	tracker := NewSLOTracker(WithSLO(
+		SLO{Route: "/users/:id", Objective: 0.9},
+		SLO{Route: SLORouteAny, Objective: 0.5},
+	))
+	m := NewMonitor()
+	m.RegisterSLOTracker(tracker)
+	h := NewHandler(nil, WithMonitor(m), WithSLOTracker(tracker))
+
+	engine := gin.New()
+	engine.Use(h.ErrorMiddleware(), h.RecoveryMiddleware())
+	engine.GET("/users/:id", func(c *gin.Context) {
+		switch c.Param("id") {
+		case "invalid":
+			_ = c.Error(New(ErrValidation))
+		case "down":
+			_ = c.Error(New(ErrExternal))
+		case "boom":
+			panic("boom")
+		default:
+			c.Status(http.StatusOK)
+		}
+	})
+	engine.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/invalid", "/users/down", "/users/boom", "/health", "/missing"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	slos := m.Snapshot().SLOs
+	if len(slos) != 2 || slos[0].Route != "/health" || slos[1].Route != "/users/:id" {
+		t.Fatalf("slos = %+v", slos)
+	}
+	if slos[1].Total != 5 || slos[1].Bad != 2 || slos[0].Total != 1 || slos[0].Bad != 0 {
+		t.Errorf("slos = %+v", slos)
+	}
+
+	var sb strings.Builder
+	if err := m.WritePrometheus(&sb); err != nil {
+		t.Fatal(err)
+	}
+	out := sb.String()
+	for _, want := range []string{
+		"# TYPE errors_total counter\nerrors_total 3\n",
+		`errors_by_code_total{code="VALIDATION"} 1`,
+		`errors_by_status_total{status="500"} 1`,
+		`slo_requests{route="/users/:id"} 5`,
+		`slo_bad_requests{route="/users/:id"} 2`,
+		`slo_burn_rate{route="/users/:id",window="5m0s"} 4`,
+		`slo_burn_rate_alerting{route="/users/:id",window="1h0m0s/5m0s"} 0`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, `slo_burn_rate{route="/users/:id",window="6h0m0s"}`) != 1 {
+		t.Error("重复的窗口只应该输出一次")
+	}
+}
+
+// TestHandler_SLORecoveryOnly 测试只使用RecoveryMiddleware时成功的请求同样计入SLO
+func TestHandler_SLORecoveryOnly(t *testing.T) {
+	tracker := NewSLOTracker(WithSLO(SLO{Route: SLORouteAny, Objective: 0.9}))
+	h := NewHandler(nil, WithSLOTracker(tracker))
+
+	engine := gin.New()
+	engine.Use(h.RecoveryMiddleware())
+	engine.GET("/users/:id", func(c *gin.Context) {
+		if c.Param("id") == "boom" {
+			panic("boom")
+		}
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/boom"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	slos := tracker.Snapshot()
+	if len(slos) != 1 || slos[0].Total != 3 || slos[0].Bad != 1 {
+		t.Errorf("slos = %+v", slos)
+	}
+}
+
+// TestHandler_SLOHTTP 测试net/http的Recover按ServeMux的路由模板记录SLO
+func TestHandler_SLOHTTP(t *testing.T) {
+	tracker := NewSLOTracker(WithSLO(SLO{Route: SLORouteAny, Objective: 0.9}))
+	h := NewHandler(nil, WithSLOTracker(tracker))
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		switch r.PathValue("id") {
+		case "boom":
+			panic("boom")
+		case "down":
+			h.WriteError(w, r, New(ErrExternal))
+		case "invalid":
+			h.WriteError(w, r, New(ErrValidation))
+		}
+	})
+	mux.Handle("GET /orders", h.HandleFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return New(ErrInternal)
+	}))
+	srv := h.Recover(mux)
+
+	for _, path := range []string{"/users/1", "/users/boom", "/users/down", "/users/invalid", "/orders", "/missing"} {
+		srv.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	slos := tracker.Snapshot()
+	if len(slos) != 2 || slos[0].Route != "GET /orders" || slos[1].Route != "GET /users/{id}" {
+		t.Fatalf("slos = %+v", slos)
+	}
+	if slos[0].Total != 1 || slos[0].Bad != 1 || slos[1].Total != 4 || slos[1].Bad != 2 {
+		t.Errorf("slos = %+v", slos)
+	}
+
+	// 自定义路由模板
+	tracker = NewSLOTracker(WithSLO(SLO{Route: SLORouteAny, Objective: 0.9}))
+	h = NewHandler(nil, WithSLOTracker(tracker), WithHTTPRoute(func(r *http.Request) string { return "custom" }))
+	h.Recover(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/x", nil))
+	if slos := tracker.Snapshot(); len(slos) != 1 || slos[0].Route != "custom" || slos[0].Total != 1 {
+		t.Errorf("slos = %+v", slos)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// placeholderPattern 命名占位符，例如 {user_id}
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_.]*)\}`)
+
+// renderTemplate 使用参数填充模板中的命名占位符，缺失的参数保留原始占位符
+func renderTemplate(tmpl string, params map[string]any) string {
+	if len(params) == 0 {
+		return tmpl
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(tmpl, func(placeholder string) string {
+		name := placeholder[1 : len(placeholder)-1]
+		val, ok := params[name]
+		if !ok {
+			return placeholder
+		}
+
+		return fmt.Sprint(val)
+	})
+}
+
+// Params 命名参数，用于渲染错误码的消息模板
+type Params map[string]any
+
+// templateParams 返回模板中引用的参数名
+func templateParams(tmpl string) []string {
+	matches := placeholderPattern.FindAllStringSubmatch(tmpl, -1)
+	names := make([]string, 0, len(matches))
+	for _, match := range matches {
+		names = append(names, match[1])
+	}
+
+	return names
+}
+
+// validateTemplate 校验模板只引用了已声明的参数
+func validateTemplate(tmpl string, known []string) error {
+	for _, name := range templateParams(tmpl) {
+		if !slices.Contains(known, name) {
+			return fmt.Errorf("template %q references unknown param %q", tmpl, name)
+		}
+	}
+
+	return nil
+}
+
+// nw 使用命名参数创建错误，消息模板渲染后作为错误信息，所有参数写入元数据
+func nw(enableStack bool, code *ErrCode, params Params) *ErrorImpl {
+	impl := acquireError()
+	impl.code = code.Code
+	impl.message = renderTemplate(code.Message, params)
+	impl.publicMessage = renderTemplate(code.publicMessage(), params)
+	impl.messageKey = code.messageKey()
+	impl.httpStatus = code.HttpStatus
+	impl.errType = code.Type
+	impl.retryable = code.Retryable
+	impl.severity = code.Severity
+	impl.timestamp = time.Now().UTC()
+	for k, v := range params {
+		impl.metadata[k] = v
+	}
+
+	if enableStack {
+		impl.stackTrace = getSimplifiedStackTrace(2, 6)
+	}
+
+	return impl
+}
+
+// NewWith 使用命名参数创建带堆栈信息的错误，例如错误码的消息为
+// "user {user_id} not found in {tenant}"，参数会渲染到消息中并写入元数据
+func NewWith(code *ErrCode, params Params) Error {
+	impl := nw(true, code, params)
+	fireCreate(impl)
+	return impl
+}
+
+// FastNewWith 使用命名参数创建不带堆栈信息的错误，适用于性能敏感场景
+func FastNewWith(code *ErrCode, params Params) Error {
+	impl := nw(false, code, params)
+	fireCreate(impl)
+	return impl
+}
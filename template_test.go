@@ -0,0 +1,124 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestNewWith 测试命名参数模板的渲染
+func TestNewWith(t *testing.T) {
+	code := &ErrCode{
+		Code:       "TEST_USER_NOT_FOUND",
+		Message:    "user {user_id} not found in {tenant}",
+		HttpStatus: http.StatusNotFound,
+		Type:       ErrTypeNotFound,
+		Params:     []string{"user_id", "tenant"},
+	}
+
+	err := NewWith(code, Params{"user_id": 42, "tenant": "acme"})
+	if err.Message() != "user 42 not found in acme" {
+		t.Errorf("Message() = %s, want %s", err.Message(), "user 42 not found in acme")
+	}
+	if err.Metadata()["user_id"] != 42 || err.Metadata()["tenant"] != "acme" {
+		t.Errorf("Metadata() = %v", err.Metadata())
+	}
+	if err.StackTrace() == "" {
+		t.Error("NewWith应该记录堆栈信息")
+	}
+	if FastNewWith(code, Params{"user_id": 1}).StackTrace() != "" {
+		t.Error("FastNewWith不应该记录堆栈信息")
+	}
+
+	// 缺失的参数保留占位符
+	if got := FastNewWith(code, Params{"user_id": 1}).Message(); got != "user 1 not found in {tenant}" {
+		t.Errorf("Message() = %s", got)
+	}
+}
+
+// TestRegister 测试注册时的模板校验
+func TestRegister(t *testing.T) {
+	valid := &ErrCode{
+		Code:       "TEST_ORDER_LOCKED",
+		Message:    "order {order_id} locked",
+		HttpStatus: http.StatusConflict,
+		Type:       ErrTypeConflict,
+		Params:     []string{"order_id"},
+	}
+	if err := Register(valid); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := Register(valid); err != nil {
+		t.Errorf("重复注册同一个错误码不应该失败: %v", err)
+	}
+	if got, ok := LookupCode(valid.Code); !ok || got != valid {
+		t.Error("LookupCode() 应该返回已注册的错误码")
+	}
+
+	duplicated := *valid
+	if err := Register(&duplicated); err == nil {
+		t.Error("注册重复的消息键应该失败")
+	}
+
+	// Code相同但MessageKey不同的错误码可以同时注册
+	for _, code := range []*ErrCode{ErrUsernameExisted, ErrEmailExisted, ErrPhoneExisted} {
+		if err := Register(code); err != nil {
+			t.Errorf("Register(%s) error = %v", code.MessageKey, err)
+		}
+		if got, ok := lookupMessageKey(code.MessageKey); !ok || got != code {
+			t.Errorf("lookupMessageKey(%s) = %v", code.MessageKey, got)
+		}
+	}
+	if got, ok := LookupCode(ErrConflict.Code); !ok || got.Code != ErrConflict.Code {
+		t.Errorf("LookupCode(%s) = %v", ErrConflict.Code, got)
+	}
+
+	unknown := &ErrCode{
+		Code:    "TEST_UNKNOWN_PARAM",
+		Message: "order {order_id} locked by {user}",
+		Params:  []string{"order_id"},
+	}
+	if err := Register(unknown); err == nil {
+		t.Error("模板引用未声明的参数时注册应该失败")
+	}
+
+	c := NewCatalog("en").Add("zh", valid.Code, "订单 {order} 已锁定")
+	if err := c.Validate(); err == nil {
+		t.Error("翻译引用未声明的参数时校验应该失败")
+	}
+}
+
+// TestNewWith_Localize 测试命名参数在本地化时被使用
+func TestNewWith_Localize(t *testing.T) {
+	code := MustRegister(&ErrCode{
+		Code:       "TEST_QUOTA_EXCEEDED",
+		Message:    "quota {quota} exceeded",
+		HttpStatus: http.StatusTooManyRequests,
+		Type:       ErrTypeRateLimit,
+		Params:     []string{"quota"},
+	})
+	c := NewCatalog("en").Add("zh", code.Code, "超出配额 {quota}")
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "zh-CN")
+	rec := httptest.NewRecorder()
+	NewHandler(nil, WithCatalog(c)).WriteError(rec, req, NewWith(code, Params{"quota": 100}))
+
+	if resp := decodeErrorResponse(t, rec); resp.Message != "超出配额 100" {
+		t.Errorf("Message = %s, want %s", resp.Message, "超出配额 100")
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	// 字段路径，例如 address.city、items[0].name
+	Field string `json:"field"`
+	// 校验规则，例如 required、email、min
+	Rule string `json:"rule"`
+	// 规则参数，例如 min=3 中的3
+	Param string `json:"param,omitempty"`
+	// 错误信息
+	Message string `json:"message"`
+}
+
+// ValidationMessages 校验规则对应的默认错误信息模板，可使用 {field} 和 {param} 占位符
+// 未配置的规则使用 "*" 对应的模板
+var ValidationMessages = map[string]string{
+	"required": "{field} is required",
+	"email":    "{field} must be a valid email address",
+	"url":      "{field} must be a valid URL",
+	"uuid":     "{field} must be a valid UUID",
+	"min":      "{field} must be at least {param}",
+	"max":      "{field} must be at most {param}",
+	"len":      "{field} must be exactly {param} in length",
+	"gte":      "{field} must be greater than or equal to {param}",
+	"lte":      "{field} must be less than or equal to {param}",
+	"gt":       "{field} must be greater than {param}",
+	"lt":       "{field} must be less than {param}",
+	"oneof":    "{field} must be one of [{param}]",
+	"type":     "{field} must be of type {param}",
+	"*":        "{field} failed on the '{rule}' rule",
+}
+
+// ValidationError 字段级别的校验错误，类型为VALIDATION，包含所有字段的错误信息
+type ValidationError struct {
+	*ErrorImpl
+	// 字段错误
+	fields []FieldError
+}
+
+// NewValidationError 创建带堆栈信息的校验错误，创建钩子收到的是*ValidationError
+func NewValidationError(fields ...FieldError) *ValidationError {
+	ve := &ValidationError{
+		ErrorImpl: n(true, ErrValidation),
+		fields:    fields,
+	}
+
+	fireCreate(ve)
+	return ve
+}
+
+// AddField 添加字段错误，message为空时根据ValidationMessages生成
+func (e *ValidationError) AddField(field, rule, param, message string) *ValidationError {
+	e.fields = append(e.fields, newFieldError(field, rule, param, message))
+	return e
+}
+
+// newFieldError 创建字段错误，message为空时根据ValidationMessages生成
+func newFieldError(field, rule, param, message string) FieldError {
+	if message == "" {
+		message = validationMessage(field, rule, param)
+	}
+
+	return FieldError{
+		Field:   field,
+		Rule:    rule,
+		Param:   param,
+		Message: message,
+	}
+}
+
+// Fields 返回所有字段错误
+func (e *ValidationError) Fields() []FieldError {
+	return e.fields
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.fields) == 0 {
+		return e.ErrorImpl.Error()
+	}
+
+	messages := make([]string, 0, len(e.fields))
+	for _, f := range e.fields {
+		messages = append(messages, f.Message)
+	}
+
+	return e.message + ": " + strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) WithMetadata(key string, val any) Error {
+	e.ErrorImpl.WithMetadata(key, val)
+	return e
+}
+
+func (e *ValidationError) WithMetadataMap(metadata map[string]any) Error {
+	e.ErrorImpl.WithMetadataMap(metadata)
+	return e
+}
+
+// JSONFieldName 返回结构体字段的json标签名，可用于validator.RegisterTagNameFunc，
+// 没有json标签或标签为-时返回空字符串，validator会使用Go字段名
+func JSONFieldName(fld reflect.StructField) string {
+	name, _, _ := strings.Cut(fld.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+
+	return name
+}
+
+// RegisterJSONFieldNames 让v的字段错误使用json标签名，FieldError.Field因此与请求体的字段路径一致，
+// 本包初始化时已经为Gin默认的binding.Validator注册，自行创建的validator需要调用该函数
+func RegisterJSONFieldNames(v *validator.Validate) {
+	v.RegisterTagNameFunc(JSONFieldName)
+}
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		RegisterJSONFieldNames(v)
+	}
+}
+
+// FromValidator 将validator.ValidationErrors转换为ValidationError，
+// err中不包含validator.ValidationErrors时返回nil。字段路径去掉顶层结构体名，
+// 校验器通过RegisterJSONFieldNames注册后使用json标签名，否则使用Go字段名
+func FromValidator(err error) Error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil
+	}
+
+	return NewValidationError(validatorFields("", validationErrs)...)
+}
+
+// bindingBodyField 无法确定具体字段时使用的字段名
+const bindingBodyField = "body"
+
+// FromBindingError 将Gin绑定请求参数时返回的错误转换为Error
+// 校验错误转换为ValidationError，JSON类型错误转换为对应字段的type错误，
+// 数字解析错误无法得知字段，转换为body字段的type错误，输入值只出现在脱敏后的错误信息中，
+// 其它错误按BAD_REQUEST处理
+func FromBindingError(err error) Error {
+	if err == nil {
+		return nil
+	}
+
+	var (
+		validationErrs validator.ValidationErrors
+		sliceErrs      binding.SliceValidationError
+		typeErr        *json.UnmarshalTypeError
+		numErr         *strconv.NumError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		return NewValidationError(validatorFields("", validationErrs)...)
+	case errors.As(err, &sliceErrs):
+		var fields []FieldError
+		for i, itemErr := range sliceErrs {
+			if errors.As(itemErr, &validationErrs) {
+				fields = append(fields, validatorFields("["+strconv.Itoa(i)+"]", validationErrs)...)
+			}
+		}
+		return NewValidationError(fields...)
+	case errors.As(err, &typeErr):
+		// Field为从顶层开始的完整字段路径，整个请求体类型不匹配时为空
+		field := typeErr.Field
+		if field == "" {
+			field = bindingBodyField
+		}
+		return NewValidationError(newFieldError(field, "type", typeErr.Type.String(), ""))
+	case errors.As(err, &numErr):
+		message := DefaultRedactor().RedactString(fmt.Sprintf("value %q must be of type number", numErr.Num))
+		return NewValidationError(newFieldError(bindingBodyField, "type", "number", message))
+	default:
+		return Wrap(err, ErrBadRequest)
+	}
+}
+
+// validatorFields 将validator的字段错误转换为FieldError，prefix为字段路径前缀
+func validatorFields(prefix string, errs validator.ValidationErrors) []FieldError {
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		field := fieldPath(fe)
+		if prefix != "" {
+			field = prefix + "." + field
+		}
+		fields = append(fields, newFieldError(field, fe.Tag(), fe.Param(), ""))
+	}
+
+	return fields
+}
+
+// fieldPath 返回去掉顶层结构体名的字段路径，字段名由校验器的标签名函数决定
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+
+	return fe.Field()
+}
+
+// validationMessage 根据校验规则生成默认的错误信息
+func validationMessage(field, rule, param string) string {
+	tmpl, ok := ValidationMessages[rule]
+	if !ok {
+		tmpl = ValidationMessages["*"]
+	}
+
+	return renderTemplate(tmpl, map[string]any{
+		"field": field,
+		"rule":  rule,
+		"param": param,
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 TimeWtr
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package errors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type createUserRequest struct {
+	Name    string `json:"name" binding:"required" validate:"required"`
+	Email   string `json:"email" binding:"required,email" validate:"required,email"`
+	Age     int    `json:"age" binding:"gte=18" validate:"gte=18"`
+	Address struct {
+		City string `json:"city" binding:"required" validate:"required"`
+	} `json:"address"`
+}
+
+// TestFromValidator 测试validator错误的转换
+func TestFromValidator(t *testing.T) {
+	v := validator.New()
+	RegisterJSONFieldNames(v)
+	err := v.Struct(createUserRequest{Email: "bad", Age: 10})
+
+	converted := FromValidator(err)
+	var ve *ValidationError
+	if !errors.As(converted, &ve) {
+		t.Fatalf("期望得到ValidationError，但得到了 %v", converted)
+	}
+	if ve.Type() != ErrTypeValidation || ve.HttpStatus() != http.StatusBadRequest {
+		t.Errorf("Type() = %v, HttpStatus() = %d", ve.Type(), ve.HttpStatus())
+	}
+
+	want := []FieldError{
+		{Field: "name", Rule: "required", Message: "name is required"},
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "age", Rule: "gte", Param: "18", Message: "age must be greater than or equal to 18"},
+		{Field: "address.city", Rule: "required", Message: "address.city is required"},
+	}
+	fields := ve.Fields()
+	if len(fields) != len(want) {
+		t.Fatalf("Fields() = %+v, want %+v", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("Fields()[%d] = %+v, want %+v", i, fields[i], want[i])
+		}
+	}
+
+	// 未注册标签名函数时使用Go字段名
+	plain := FromValidator(validator.New().Struct(createUserRequest{Name: "a", Email: "a@b.com", Age: 20}))
+	if fields := plain.(*ValidationError).Fields(); len(fields) != 1 || fields[0].Field != "Address.City" {
+		t.Errorf("未注册时 Fields() = %+v", fields)
+	}
+
+	if FromValidator(errors.New("other")) != nil {
+		t.Error("非校验错误应该返回nil")
+	}
+}
+
+// TestFromBindingError 测试Gin绑定错误的转换和HTTP响应
+func TestFromBindingError(t *testing.T) {
+	h := NewHandler(nil)
+	engine := gin.New()
+	engine.Use(h.ErrorMiddleware())
+	engine.POST("/users", func(c *gin.Context) {
+		var req createUserRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(FromBindingError(err))
+		}
+	})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantFields []string
+	}{
+		{
+			name:       "字段校验失败",
+			body:       `{"email":"bad","age":20,"address":{"city":"sh"}}`,
+			wantFields: []string{"name", "email"},
+		},
+		{
+			name:       "字段类型错误",
+			body:       `{"name":"a","email":"a@b.com","age":"x"}`,
+			wantFields: []string{"age"},
+		},
+		{
+			name:       "嵌套字段类型错误",
+			body:       `{"name":"a","email":"a@b.com","age":20,"address":{"city":1}}`,
+			wantFields: []string{"address.city"},
+		},
+		{
+			name:       "请求体类型错误",
+			body:       `["secret-input"]`,
+			wantFields: []string{"body"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(tt.body)))
+
+			if rec.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want %d", rec.Code, http.StatusBadRequest)
+			}
+
+			resp := decodeErrorResponse(t, rec)
+			if resp.Type != ErrTypeValidation {
+				t.Errorf("Type = %s, want %s", resp.Type, ErrTypeValidation)
+			}
+			if len(resp.Fields) != len(tt.wantFields) {
+				t.Fatalf("Fields = %+v, want %v", resp.Fields, tt.wantFields)
+			}
+			for i, field := range tt.wantFields {
+				if resp.Fields[i].Field != field {
+					t.Errorf("Fields[%d].Field = %s, want %s", i, resp.Fields[i].Field, field)
+				}
+			}
+		})
+	}
+}
+
+// TestFromBindingError_NumError 测试数字解析错误不把输入值作为字段名
+func TestFromBindingError_NumError(t *testing.T) {
+	err := FromBindingError(&strconv.NumError{Func: "ParseInt", Num: "4111111111111111", Err: strconv.ErrSyntax})
+
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("FromBindingError() = %T, want *ValidationError", err)
+	}
+	fields := ve.Fields()
+	if len(fields) != 1 || fields[0].Field != "body" {
+		t.Fatalf("Fields = %+v, want body", fields)
+	}
+	if strings.Contains(fields[0].Message, "4111111111111111") {
+		t.Errorf("Message包含未脱敏的输入值: %s", fields[0].Message)
+	}
+}